@@ -0,0 +1,193 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// Acknowledgement records that someone is taking care of a failing endpoint or group (if endpoint
+// is empty). It is removed when the endpoint or group is green again.
+type Acknowledgement struct {
+	Group    string    `json:"group"`
+	Endpoint string    `json:"endpoint,omitempty"`
+	ID       string    `json:"id,omitempty"` // ID of the endpoint
+	Author   string    `json:"author"`
+	Comment  string    `json:"comment,omitempty"`
+	Created  time.Time `json:"created"`
+}
+
+// AckRequest acknowledges a failing endpoint or group via the API
+type AckRequest struct {
+	Group    string `json:"group"`
+	Endpoint string `json:"endpoint"`
+	Comment  string `json:"comment"`
+}
+
+// AckResponse lists all acknowledgements
+type AckResponse struct {
+	Acknowledgements []*Acknowledgement `json:"acknowledgements"`
+}
+
+func (s *Server) handleAck(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.respond(w, r, AckResponse{Acknowledgements: s.state.Acknowledgements()})
+
+	case http.MethodPost, http.MethodDelete:
+		// Acknowledgements name who takes care of an incident, so they are only changed by known users
+		user := s.requestUser(r)
+		if user == "" {
+			s.respond(w, r, Error{
+				Code:    403,
+				Message: "Acknowledgements can only be changed by authenticated users",
+			})
+			return
+		}
+		if r.Method == http.MethodDelete {
+			query := r.URL.Query()
+			s.respond(w, r, s.deleteAck(query.Get("group"), query.Get("endpoint"), user))
+			return
+		}
+
+		request := AckRequest{}
+		err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 64*1024)).Decode(&request)
+		if err != nil {
+			s.respond(w, r, Error{
+				Code:    400,
+				Message: "Invalid acknowledgement request: " + err.Error(),
+			})
+			return
+		}
+		s.respond(w, r, s.createAck(request, user))
+
+	default:
+		s.respond(w, r, Error{
+			Code:    405,
+			Message: "Method not allowed",
+		})
+	}
+}
+
+func (s *Server) createAck(request AckRequest, user string) any {
+	group := s.groupByName(request.Group)
+	if group == nil {
+		return Error{
+			Code:    400,
+			Message: "Invalid group/endpoint selection",
+		}
+	}
+
+	var status Status
+	id := ""
+	if request.Endpoint == "" {
+		s.resultsMutex.Lock()
+		status = s.groupStatus[group.Name]
+		s.resultsMutex.Unlock()
+	} else {
+		endpoint := s.endpointByName(group, request.Endpoint)
+		if endpoint == nil {
+			return Error{
+				Code:    400,
+				Message: "Invalid group/endpoint selection",
+			}
+		}
+
+		id = endpoint.ID
+		s.resultsMutex.Lock()
+		if result, ok := s.results[endpoint.ID]; ok {
+			status = result.Status
+		}
+		s.resultsMutex.Unlock()
+	}
+
+	if status != STATUS_RED && status != STATUS_YELLOW {
+		return Error{
+			Code:    400,
+			Message: "Only failing endpoints and groups can be acknowledged",
+		}
+	}
+
+	ack := &Acknowledgement{
+		Group:    request.Group,
+		Endpoint: request.Endpoint,
+		ID:       id,
+		Author:   user,
+		Comment:  request.Comment,
+		Created:  time.Now(),
+	}
+
+	err := s.state.SetAcknowledgement(ack)
+	if err != nil {
+		outError("Cannot save acknowledgement: %s\n", err.Error())
+	}
+	out("%s/%s acknowledged by %s: %s\n", ack.Group, ack.Endpoint, ack.Author, ack.Comment)
+
+	s.publishAcks()
+	return ack
+}
+
+// matches returns whether the acknowledgement is the one of the endpoint with the given ID or the
+// group (if id is empty)
+func (a *Acknowledgement) matches(groupName string, id string) bool {
+	if id != "" {
+		return a.ID == id
+	}
+	return a.Endpoint == "" && a.Group == groupName
+}
+
+func (s *Server) deleteAck(groupName string, endpointName string, user string) any {
+	id := ""
+	if endpointName != "" {
+		var endpoint *Endpoint
+		if group := s.groupByName(groupName); group != nil {
+			endpoint = s.endpointByName(group, endpointName)
+		}
+		if endpoint == nil {
+			return Error{
+				Code:    404,
+				Message: "Acknowledgement not found",
+			}
+		}
+		id = endpoint.ID
+	}
+
+	ack, err := s.state.RemoveAcknowledgement(groupName, id)
+	if err != nil {
+		outError("Cannot save acknowledgements: %s\n", err.Error())
+	}
+	if ack == nil {
+		return Error{
+			Code:    404,
+			Message: "Acknowledgement not found",
+		}
+	}
+	out("Acknowledgement of %s/%s removed by %s\n", ack.Group, ack.Endpoint, user)
+
+	s.publishAcks()
+	return ack
+}
+
+// clearAck removes the acknowledgement of the endpoint with the given ID or the group (if id is
+// empty) after it recovered
+func (s *Server) clearAck(groupName string, id string) {
+	if !s.state.Acknowledged(groupName, id) {
+		return
+	}
+
+	ack, err := s.state.RemoveAcknowledgement(groupName, id)
+	if err != nil {
+		outError("Cannot save acknowledgements: %s\n", err.Error())
+	}
+	if ack != nil {
+		outDebug("Acknowledgement of %s/%s cleared after recovery\n", ack.Group, ack.Endpoint)
+		s.publishAcks()
+	}
+}
+
+func (s *Server) publishAcks() {
+	s.events.Publish(Event{
+		Type: EVENT_TYPE_ACK,
+		Data: AckResponse{Acknowledgements: s.state.Acknowledgements()},
+	})
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newAckTestServer(t *testing.T, authorization string) *Server {
+	t.Helper()
+	config, err := readTestConfiguration(t, `
+refreshInterval: 10
+authorization:
+`+authorization+`
+groups:
+  - name: Shops
+    endpoints:
+      - name: Checkout
+        url: http://example.com/checkout
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	state, _ := NewState("")
+	s := &Server{
+		results:     map[string]*Result{"Shops/Checkout": {Status: STATUS_RED}},
+		groupStatus: map[string]Status{"Shops": STATUS_RED},
+		state:       state,
+		events:      NewEventHub(),
+	}
+	s.configuration.Store(config)
+	return s
+}
+
+func ackRequest(s *Server, method string, target string, body string, subject string) map[string]any {
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	if subject != "" {
+		r.Header.Set("X-SSL-Client-S-DN", subject)
+	}
+	w := httptest.NewRecorder()
+	s.handleAPIRequest(w, r)
+
+	response := make(map[string]any)
+	_ = json.Unmarshal(w.Body.Bytes(), &response)
+	return response
+}
+
+func TestAckRequiresUser(t *testing.T) {
+	s := newAckTestServer(t, "  type: none")
+
+	tests := []struct {
+		method string
+		target string
+		body   string
+	}{
+		{http.MethodPost, "/api/ack", `{"group": "Shops", "endpoint": "Checkout", "author": "alice"}`},
+		{http.MethodPost, "/api/ack", `{"group": "Shops"}`},
+		{http.MethodDelete, "/api/ack?group=Shops&endpoint=Checkout", ""},
+	}
+
+	for _, test := range tests {
+		response := ackRequest(s, test.method, test.target, test.body, "")
+		if response["Code"] != float64(403) {
+			t.Errorf("%s %s: expected 403, got %v", test.method, test.target, response)
+		}
+	}
+	if acks := s.state.Acknowledgements(); len(acks) != 0 {
+		t.Errorf("expected no acknowledgements, got %d", len(acks))
+	}
+}
+
+func TestAckAuthor(t *testing.T) {
+	s := newAckTestServer(t, `  type: client-cert-info
+  header: X-SSL-Client-S-DN
+  users: [alice, bob]`)
+
+	// The author of the body is ignored
+	response := ackRequest(s, http.MethodPost, "/api/ack", `{"group": "Shops", "endpoint": "Checkout", "comment": "On it", "author": "mallory"}`, "CN=alice,O=Example")
+	if response["author"] != "alice" || response["comment"] != "On it" || response["id"] != "Shops/Checkout" {
+		t.Fatalf("unexpected acknowledgement %v", response)
+	}
+	if !s.state.Acknowledged("Shops", "Shops/Checkout") {
+		t.Error("acknowledgement not stored")
+	}
+
+	// Without certificate the request is not authorized at all
+	response = ackRequest(s, http.MethodDelete, "/api/ack?group=Shops&endpoint=Checkout", "", "")
+	if response["Code"] != float64(401) {
+		t.Errorf("expected 401, got %v", response)
+	}
+
+	response = ackRequest(s, http.MethodDelete, "/api/ack?group=Shops&endpoint=Checkout", "", "CN=bob")
+	if response["author"] != "alice" {
+		t.Errorf("expected the removed acknowledgement, got %v", response)
+	}
+	if s.state.Acknowledged("Shops", "Shops/Checkout") {
+		t.Error("acknowledgement not removed")
+	}
+}
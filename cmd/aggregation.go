@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+)
+
+const aggregationDefaultRedShare = 0.5
+
+// AggregationConfiguration defines how the status of a group is computed from the status of its
+// endpoints and subgroups. Endpoints and subgroups count with their weight, inactive ones and ones
+// in maintenance are ignored and blocked endpoints count as yellow. Red critical endpoints and
+// subgroups make the group red regardless of the strategy.
+//
+//   - default: red if more endpoints are red than green, yellow if any endpoint is red or yellow
+//   - worst: the worst status of all endpoints
+//   - majority: the status of most endpoints, on a tie the worse one
+//   - threshold: red if the share of red endpoints reaches red, yellow if the share of red and
+//     yellow endpoints reaches yellow (a share between 0 and 1)
+type AggregationConfiguration struct {
+	Strategy string  `yaml:"strategy,omitempty" json:"strategy,omitempty"`
+	Red      float64 `yaml:"red,omitempty" json:"red,omitempty"`
+	Yellow   float64 `yaml:"yellow,omitempty" json:"yellow,omitempty"`
+}
+
+// prepare takes the defaults if no strategy is set and validates the configuration
+func (a *AggregationConfiguration) prepare(defaults AggregationConfiguration) error {
+	if a.Strategy == "" {
+		*a = defaults
+	}
+
+	switch a.Strategy {
+	case "":
+		a.Strategy = AGGREGATION_DEFAULT
+	case AGGREGATION_DEFAULT, AGGREGATION_WORST, AGGREGATION_MAJORITY:
+		// Valid
+	case AGGREGATION_THRESHOLD:
+		if a.Red < 0 || a.Red > 1 || a.Yellow < 0 || a.Yellow > 1 {
+			return fmt.Errorf("red and yellow must be between 0 and 1")
+		}
+		if a.Red == 0 {
+			a.Red = aggregationDefaultRedShare
+		}
+	default:
+		return fmt.Errorf("strategy must be one of \"%s\", \"%s\", \"%s\" or \"%s\"", AGGREGATION_DEFAULT, AGGREGATION_WORST, AGGREGATION_MAJORITY, AGGREGATION_THRESHOLD)
+	}
+	return nil
+}
+
+// weight returns the weight of the endpoint for the group status
+func (e *Endpoint) weight() float64 {
+	if e.Weight == 0 {
+		return 1
+	}
+	return e.Weight
+}
+
+// aggregate computes the group status from the status of the endpoints and the (already computed)
+// status of the subgroups. Must be called with resultsMutex locked.
+func (s *Server) aggregate(group *Group) Status {
+	weights := make(map[Status]float64)
+	total := 0.0
+	maintenance := false
+	critical := false
+	count := func(status Status, weight float64, isCritical bool) {
+		switch status {
+		case STATUS_RED:
+			critical = critical || isCritical
+		case STATUS_BLOCKED:
+			status = STATUS_YELLOW
+		case STATUS_MAINTENANCE:
+			maintenance = true
+			return
+		case STATUS_GREEN, STATUS_YELLOW:
+			// Counted as is
+		default:
+			return
+		}
+		weights[status] += weight
+		total += weight
+	}
+
+	for _, endpoint := range group.Endpoints {
+		if result, ok := s.results[endpoint.ID]; ok {
+			count(result.Status, endpoint.weight(), endpoint.Critical)
+		}
+	}
+	for _, subgroup := range group.Groups {
+		count(s.groupStatus[subgroup.Name], subgroup.weight(), subgroup.Critical)
+	}
+
+	if critical {
+		return STATUS_RED
+	}
+
+	if total == 0 {
+		if maintenance {
+			return STATUS_MAINTENANCE
+		}
+		return STATUS_INACTIVE
+	}
+
+	red, yellow, green := weights[STATUS_RED], weights[STATUS_YELLOW], weights[STATUS_GREEN]
+	switch group.Aggregation.Strategy {
+	case AGGREGATION_WORST:
+		if red > 0 {
+			return STATUS_RED
+		} else if yellow > 0 {
+			return STATUS_YELLOW
+		}
+		return STATUS_GREEN
+
+	case AGGREGATION_MAJORITY:
+		if red >= yellow && red >= green {
+			return STATUS_RED
+		} else if yellow >= green {
+			return STATUS_YELLOW
+		}
+		return STATUS_GREEN
+
+	case AGGREGATION_THRESHOLD:
+		if red/total >= group.Aggregation.Red {
+			return STATUS_RED
+		} else if red+yellow > 0 && (red+yellow)/total >= group.Aggregation.Yellow {
+			return STATUS_YELLOW
+		}
+		return STATUS_GREEN
+	}
+
+	if red > green {
+		return STATUS_RED
+	} else if red > 0 || yellow > 0 {
+		return STATUS_YELLOW
+	}
+	return STATUS_GREEN
+}
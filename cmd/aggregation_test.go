@@ -0,0 +1,93 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestAggregate(t *testing.T) {
+	tests := []struct {
+		aggregation AggregationConfiguration
+		statuses    string // Lower case for critical endpoints
+		weights     []float64
+		expected    Status
+	}{
+		{AggregationConfiguration{}, "GGG", nil, STATUS_GREEN},
+		{AggregationConfiguration{}, "GGR", nil, STATUS_YELLOW},
+		{AggregationConfiguration{}, "GRR", nil, STATUS_RED},
+		{AggregationConfiguration{}, "GYY", nil, STATUS_YELLOW},
+		{AggregationConfiguration{}, "GR", []float64{1, 2}, STATUS_RED},
+		{AggregationConfiguration{}, "GR", []float64{2, 1}, STATUS_YELLOW},
+		// Blocked endpoints count as yellow, maintenance and inactive ones not at all
+		{AggregationConfiguration{}, "GB", nil, STATUS_YELLOW},
+		{AggregationConfiguration{}, "RB", nil, STATUS_RED},
+		{AggregationConfiguration{}, "MG", nil, STATUS_GREEN},
+		{AggregationConfiguration{}, "IG", nil, STATUS_GREEN},
+		{AggregationConfiguration{}, "MI", nil, STATUS_MAINTENANCE},
+		{AggregationConfiguration{}, "II", nil, STATUS_INACTIVE},
+		{AggregationConfiguration{}, "", nil, STATUS_INACTIVE},
+		// Critical endpoints
+		{AggregationConfiguration{}, "GGr", nil, STATUS_RED},
+		{AggregationConfiguration{}, "GGRg", nil, STATUS_YELLOW},
+		{AggregationConfiguration{Strategy: AGGREGATION_MAJORITY}, "GGGr", nil, STATUS_RED},
+
+		{AggregationConfiguration{Strategy: AGGREGATION_WORST}, "GGY", nil, STATUS_YELLOW},
+		{AggregationConfiguration{Strategy: AGGREGATION_WORST}, "GGR", nil, STATUS_RED},
+		{AggregationConfiguration{Strategy: AGGREGATION_WORST}, "GBG", nil, STATUS_YELLOW},
+		{AggregationConfiguration{Strategy: AGGREGATION_WORST}, "GMG", nil, STATUS_GREEN},
+
+		{AggregationConfiguration{Strategy: AGGREGATION_MAJORITY}, "GGR", nil, STATUS_GREEN},
+		{AggregationConfiguration{Strategy: AGGREGATION_MAJORITY}, "GRR", nil, STATUS_RED},
+		{AggregationConfiguration{Strategy: AGGREGATION_MAJORITY}, "GYR", nil, STATUS_RED},
+		{AggregationConfiguration{Strategy: AGGREGATION_MAJORITY}, "GGYY", nil, STATUS_YELLOW},
+		{AggregationConfiguration{Strategy: AGGREGATION_MAJORITY}, "GGY", []float64{1, 1, 3}, STATUS_YELLOW},
+
+		{AggregationConfiguration{Strategy: AGGREGATION_THRESHOLD, Red: 0.5, Yellow: 0.3}, "GGGR", nil, STATUS_GREEN},
+		{AggregationConfiguration{Strategy: AGGREGATION_THRESHOLD, Red: 0.5, Yellow: 0.3}, "GGR", nil, STATUS_YELLOW},
+		{AggregationConfiguration{Strategy: AGGREGATION_THRESHOLD, Red: 0.5, Yellow: 0.3}, "GR", nil, STATUS_RED},
+		{AggregationConfiguration{Strategy: AGGREGATION_THRESHOLD, Red: 0.5, Yellow: 0.3}, "GGYR", nil, STATUS_YELLOW},
+		// Default shares: red from 0.5, yellow for any failing endpoint
+		{AggregationConfiguration{Strategy: AGGREGATION_THRESHOLD}, "GGGY", nil, STATUS_YELLOW},
+		{AggregationConfiguration{Strategy: AGGREGATION_THRESHOLD}, "GGRR", nil, STATUS_RED},
+		{AggregationConfiguration{Strategy: AGGREGATION_THRESHOLD}, "GGGG", nil, STATUS_GREEN},
+	}
+
+	for _, test := range tests {
+		group := &Group{Name: "Group", Aggregation: test.aggregation}
+		if err := group.Aggregation.prepare(AggregationConfiguration{}); err != nil {
+			t.Fatal(err)
+		}
+		s := &Server{
+			results:     make(map[string]*Result),
+			groupStatus: make(map[string]Status),
+		}
+		for i := range test.statuses {
+			letter := strings.ToUpper(test.statuses[i : i+1])
+			endpoint := &Endpoint{ID: strconv.Itoa(i), Critical: letter != test.statuses[i:i+1]}
+			if test.weights != nil {
+				endpoint.Weight = test.weights[i]
+			}
+			group.Endpoints = append(group.Endpoints, endpoint)
+			s.results[endpoint.ID] = &Result{Status: testStatusLetters[letter[0]]}
+		}
+
+		if status := s.aggregate(group); status != test.expected {
+			t.Errorf("%s %s %v: expected %s, got %s", group.Aggregation.Strategy, test.statuses, test.weights, test.expected, status)
+		}
+	}
+}
+
+func TestAggregationConfigurationErrors(t *testing.T) {
+	tests := []AggregationConfiguration{
+		{Strategy: "best"},
+		{Strategy: AGGREGATION_THRESHOLD, Red: 1.5},
+		{Strategy: AGGREGATION_THRESHOLD, Yellow: -0.1},
+	}
+
+	for _, aggregation := range tests {
+		if err := aggregation.prepare(AggregationConfiguration{}); err == nil {
+			t.Errorf("%+v: expected an error", aggregation)
+		}
+	}
+}
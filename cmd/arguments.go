@@ -8,16 +8,21 @@ import (
 )
 
 type Arguments struct {
-	ConfigFile string
-	Port       uint
-	CacheFile  string
+	ConfigFile  string
+	Port        uint
+	CacheFile   string
+	HistoryFile string
+	StateFile   string
+	WatchConfig bool
 }
 
 func ParseCLIArguments() *Arguments {
 	args := Arguments{
-		Port:       8765,
-		ConfigFile: "./config.yaml",
-		CacheFile:  "./cache.json",
+		Port:        8765,
+		ConfigFile:  "./config.yaml",
+		CacheFile:   "./cache.json",
+		HistoryFile: "./history.jsonl",
+		StateFile:   "./state.json",
 	}
 
 	errors := make([]string, 0)
@@ -34,6 +39,9 @@ func ParseCLIArguments() *Arguments {
 	flag.UintVar(&args.Port, "port", args.Port, "Port to listen on (if not set, taken from env variable PORT if available, otherwise uses default)")
 	flag.StringVar(&args.ConfigFile, "config", args.ConfigFile, "Configuration file")
 	flag.StringVar(&args.CacheFile, "cache", args.CacheFile, "Cache file for results")
+	flag.StringVar(&args.HistoryFile, "history", args.HistoryFile, "History file for all results (if empty, no history is kept)")
+	flag.StringVar(&args.StateFile, "state", args.StateFile, "State file for data created at runtime, e.g. maintenance windows, acknowledgements and heartbeats (if empty, it is only kept in memory)")
+	flag.BoolVar(&args.WatchConfig, "watch", args.WatchConfig, "Reload the configuration file when it changes")
 	flag.BoolVar(&DebugMode, "debug", DebugMode, "Enable debug mode (live-frontend and logging to stdout)")
 	showHelp := flag.Bool("help", false, "Show this help")
 	flag.Parse()
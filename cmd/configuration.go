@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"path/filepath"
 	"strings"
+	"time"
 
 	"gopkg.in/yaml.v2"
 )
@@ -14,7 +16,13 @@ type Configuration struct {
 	Authorization     AuthorizationConfiguration `yaml:"authorization" json:"-"`
 	RefreshInterval   float64                    `yaml:"refreshInterval" json:"refresh_interval"`
 	DefaultHttpMethod string                     `yaml:"default_http_method" json:"-"`
+	Workers           int                        `yaml:"workers" json:"-"`
+	MaxChecksPerHost  int                        `yaml:"maxChecksPerHost" json:"-"`
 	Groups            []*Group                   `yaml:"groups" json:"groups"`
+	Aggregation       AggregationConfiguration   `yaml:"aggregation" json:"-"`
+	History           HistoryConfiguration       `yaml:"history" json:"-"`
+	Notifications     NotificationConfiguration  `yaml:"notifications" json:"-"`
+	allGroups         []*Group
 }
 
 type AuthorizationConfiguration struct {
@@ -38,6 +46,14 @@ func ReadConfiguration(configPath string) (*Configuration, error) {
 		return nil, err
 	}
 
+	switch config.Authorization.Type {
+	case AUTH_TYPE_NONE, AUTH_TYPE_CERT, AUTH_TYPE_CERT_INFO:
+		// Valid
+
+	default:
+		return nil, fmt.Errorf("authorization.type must be one of \"%s\", \"%s\" or \"%s\"", AUTH_TYPE_NONE, AUTH_TYPE_CERT, AUTH_TYPE_CERT_INFO)
+	}
+
 	if config.Authorization.Type == AUTH_TYPE_CERT && config.Authorization.Cert == "" {
 		return nil, fmt.Errorf("authorization.cert must be set when authorization.type is \"%s\"", config.Authorization.Type)
 	}
@@ -53,34 +69,149 @@ func ReadConfiguration(configPath string) (*Configuration, error) {
 		config.RefreshInterval = 10
 	}
 
-	switch config.DefaultHttpMethod {
-	case http.MethodGet, http.MethodHead:
-		// Valid
+	if config.Workers <= 0 {
+		config.Workers = workersDefault
+	}
+	if config.MaxChecksPerHost <= 0 {
+		config.MaxChecksPerHost = workersDefaultMaxPerHost
+	}
 
-	case http.MethodPost, http.MethodPut, http.MethodDelete, http.MethodPatch:
-		fmt.Fprintf(os.Stderr, "Error: Default HTTP Method %s not supported. Defaulting to GET\n", config.DefaultHttpMethod)
-		fallthrough
-	default:
+	if config.History.RetentionDays <= 0 {
+		config.History.RetentionDays = historyDefaultRetentionDays
+	}
+	if config.History.DownsampleAfterDays <= 0 {
+		config.History.DownsampleAfterDays = historyDefaultDownsampleAfterDays
+	}
+	if config.History.DownsampleMinutes <= 0 {
+		config.History.DownsampleMinutes = historyDefaultDownsampleMinutes
+	}
+
+	for _, webhook := range config.Notifications.Webhooks {
+		err = webhook.prepare()
+		if err != nil {
+			return nil, fmt.Errorf("notifications: %s", err.Error())
+		}
+	}
+
+	config.DefaultHttpMethod = strings.ToUpper(config.DefaultHttpMethod)
+	if !isSupportedHttpMethod(config.DefaultHttpMethod) {
+		if config.DefaultHttpMethod != "" {
+			fmt.Fprintf(os.Stderr, "Error: Default HTTP Method %s not supported. Defaulting to GET\n", config.DefaultHttpMethod)
+		}
 		config.DefaultHttpMethod = http.MethodGet
 	}
 
-	for _, group := range config.Groups {
+	err = config.prepareGroups()
+	if err != nil {
+		return nil, err
+	}
+
+	configDir := filepath.Dir(configPath)
+	ids := make(map[string]bool)
+	for _, group := range config.AllGroups() {
+		aggregation := config.Aggregation
+		if group.parent != nil {
+			aggregation = group.parent.Aggregation
+		}
+		err = group.Aggregation.prepare(aggregation)
+		if err != nil {
+			return nil, fmt.Errorf("group %s: aggregation.%s", group.Name, err.Error())
+		}
+
+		for _, window := range group.Maintenance {
+			err = window.prepare()
+			if err != nil {
+				return nil, fmt.Errorf("group %s: maintenance: %s", group.Name, err.Error())
+			}
+		}
+
 		for _, endpoint := range group.Endpoints {
-			endpoint.Method = strings.ToUpper(endpoint.Method)
-
-			// Validate HTTP Method
-			switch endpoint.Method {
-			case http.MethodGet, http.MethodHead:
-				// Valid
-
-			case http.MethodPost, http.MethodPut, http.MethodDelete, http.MethodPatch:
-				fmt.Fprintf(os.Stderr, "Error: HTTP Method %s for endpoint %s not supported. Defaulting to %s\n", endpoint.Method, endpoint.URL, config.DefaultHttpMethod)
-				fallthrough
-			default:
-				endpoint.Method = config.DefaultHttpMethod
+			for _, window := range endpoint.Maintenance {
+				err = window.prepare()
+				if err != nil {
+					return nil, fmt.Errorf("endpoint %s in group %s: maintenance: %s", endpoint.Name, group.Name, err.Error())
+				}
+			}
+
+			err = endpoint.prepareID(group, ids)
+			if err != nil {
+				return nil, fmt.Errorf("endpoint %s in group %s: %s", endpoint.Name, group.Name, err.Error())
+			}
+
+			if endpoint.Weight < 0 {
+				return nil, fmt.Errorf("endpoint %s in group %s: weight must not be negative", endpoint.Name, group.Name)
+			}
+
+			err = endpoint.prepareSchedule(group, config.RefreshInterval)
+			if err != nil {
+				return nil, fmt.Errorf("endpoint %s in group %s: %s", endpoint.Name, group.Name, err.Error())
+			}
+
+			err = endpoint.prepareConfirmation(group)
+			if err != nil {
+				return nil, fmt.Errorf("endpoint %s in group %s: %s", endpoint.Name, group.Name, err.Error())
+			}
+
+			err = endpoint.prepareFlapping(group)
+			if err != nil {
+				return nil, fmt.Errorf("endpoint %s in group %s: %s", endpoint.Name, group.Name, err.Error())
+			}
+
+			endpoint.RequestOptions.inherit(group.RequestOptions)
+			err = endpoint.RequestOptions.prepare(config.DefaultHttpMethod, configDir)
+			if err != nil {
+				return nil, fmt.Errorf("endpoint %s in group %s: %s", endpoint.Name, group.Name, err.Error())
+			}
+
+			err = endpoint.TargetStatus.prepare()
+			if err != nil {
+				return nil, fmt.Errorf("endpoint %s in group %s: targetStatus.%s", endpoint.Name, group.Name, err.Error())
+			}
+
+			err = endpoint.DNS.prepare()
+			if err != nil {
+				return nil, fmt.Errorf("endpoint %s in group %s: dns.%s", endpoint.Name, group.Name, err.Error())
+			}
+
+			if uri, err := EndpointURL(group, endpoint); err == nil && uri.Scheme == "heartbeat" {
+				err = endpoint.Heartbeat.prepare(configDir)
+				if err != nil {
+					return nil, fmt.Errorf("endpoint %s in group %s: heartbeat.%s", endpoint.Name, group.Name, err.Error())
+				}
 			}
 		}
 	}
 
+	err = resolveDependencies(&config)
+	if err != nil {
+		return nil, err
+	}
+
 	return &config, nil
 }
+
+// WatchConfiguration checks the configuration file regularly and calls onChange whenever its
+// modification time or size changed. It never returns.
+func WatchConfiguration(configPath string, interval time.Duration, onChange func()) {
+	var lastModified time.Time
+	var lastSize int64
+	info, err := os.Stat(configPath)
+	if err == nil {
+		lastModified, lastSize = info.ModTime(), info.Size()
+	}
+
+	for {
+		time.Sleep(interval)
+
+		info, err := os.Stat(configPath)
+		if err != nil {
+			outDebug("Cannot check configuration file %s: %s\n", configPath, err.Error())
+			continue
+		}
+
+		if !info.ModTime().Equal(lastModified) || info.Size() != lastSize {
+			lastModified, lastSize = info.ModTime(), info.Size()
+			onChange()
+		}
+	}
+}
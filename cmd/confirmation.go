@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	confirmationDefaultRetryDelay = 1
+	confirmationDefaultThreshold  = 1
+)
+
+// prepareConfirmation sets retries and thresholds of the endpoint from the group if they are not
+// set and applies the defaults
+func (e *Endpoint) prepareConfirmation(group *Group) error {
+	if e.Retries < 0 || e.RetryDelay < 0 || e.FailureThreshold < 0 || e.SuccessThreshold < 0 ||
+		group.Retries < 0 || group.RetryDelay < 0 || group.FailureThreshold < 0 || group.SuccessThreshold < 0 {
+		return fmt.Errorf("retries, retryDelay, failureThreshold and successThreshold must not be negative")
+	}
+
+	if e.Retries == 0 {
+		e.Retries = group.Retries
+	}
+	if e.RetryDelay == 0 {
+		e.RetryDelay = group.RetryDelay
+	}
+	if e.RetryDelay == 0 {
+		e.RetryDelay = confirmationDefaultRetryDelay
+	}
+	if e.FailureThreshold == 0 {
+		e.FailureThreshold = group.FailureThreshold
+	}
+	if e.FailureThreshold == 0 {
+		e.FailureThreshold = confirmationDefaultThreshold
+	}
+	if e.SuccessThreshold == 0 {
+		e.SuccessThreshold = group.SuccessThreshold
+	}
+	if e.SuccessThreshold == 0 {
+		e.SuccessThreshold = confirmationDefaultThreshold
+	}
+	return nil
+}
+
+// probeWithRetries runs the prober and repeats red checks up to the configured number of retries
+func (s *Server) probeWithRetries(prober Prober, group *Group, endpoint *Endpoint) *Result {
+	var result *Result
+	for attempt := 0; attempt <= endpoint.Retries; attempt++ {
+		if attempt > 0 {
+			outDebug("Retrying %s/%s (attempt %d)\n", group.Name, endpoint.Name, attempt+1)
+			time.Sleep(time.Duration(endpoint.RetryDelay * float64(time.Second)))
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), endpoint.timeout())
+		s.probesInFlight.Add(1)
+		result = prober.Probe(ctx, group, endpoint)
+		s.probesInFlight.Add(-1)
+		cancel()
+		applyWarning(endpoint, result)
+
+		if result.Status != STATUS_RED {
+			break
+		}
+	}
+	return result
+}
+
+// confirmStatus keeps the status of the previous result until the configured number of consecutive
+// checks disagreed with it. Until then the observed status is set as pending status of the result.
+// Worse status have to be confirmed failureThreshold times, better ones successThreshold times.
+// A blocked endpoint is red, so red checks confirm it and better ones need successThreshold checks.
+// During maintenance the status behind the maintenance is confirmed.
+func confirmStatus(endpoint *Endpoint, previous *Result, result *Result) {
+	if previous == nil {
+		return
+	}
+	confirmed := previous.Status
+	if confirmed == STATUS_MAINTENANCE {
+		// Not known after a restart, then the observed status is taken
+		confirmed = previous.checkedStatus
+	}
+	if confirmed == STATUS_BLOCKED {
+		// Blocked again after the confirmation if the dependency still fails
+		confirmed = STATUS_RED
+	}
+	if statusSeverity(confirmed) == 0 || statusSeverity(result.Status) == 0 || result.Status == confirmed {
+		return
+	}
+
+	threshold := endpoint.SuccessThreshold
+	if statusSeverity(result.Status) > statusSeverity(confirmed) {
+		threshold = endpoint.FailureThreshold
+	}
+
+	// The count starts again if the observed status differs from the pending one (e.g. red after
+	// yellow)
+	count := 1
+	if previous.PendingStatus == result.Status {
+		count = previous.PendingCount + 1
+	}
+	if count >= threshold {
+		return
+	}
+
+	result.PendingStatus = result.Status
+	result.PendingCount = count
+	result.Status = confirmed
+}
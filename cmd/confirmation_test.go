@@ -0,0 +1,116 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// runConfirmation checks the endpoint with the given sequence of observed status (as letters,
+// lower case during maintenance) like updateEndpoint and returns the resulting status (as letters)
+// and the notified transitions. If blocked is set, a dependency of the endpoint is down.
+func runConfirmation(endpoint *Endpoint, sequence string, blocked bool) (string, []string) {
+	letters := make(map[Status]string, len(testStatusLetters))
+	for letter, status := range testStatusLetters {
+		letters[status] = string(letter)
+	}
+
+	statuses := strings.Builder{}
+	notified := make([]string, 0)
+	var previous *Result
+	for i := 0; i < len(sequence); i++ {
+		letter := strings.ToUpper(sequence[i : i+1])
+		result := &Result{Status: testStatusLetters[letter[0]]}
+		confirmStatus(endpoint, previous, result)
+		if result.Status == STATUS_RED && blocked {
+			result.Status = STATUS_BLOCKED
+		}
+		if letter != sequence[i:i+1] {
+			result.checkedStatus = result.Status
+			result.Status = STATUS_MAINTENANCE
+		}
+		if oldStatus, notify := endpointTransition(previous, result); notify {
+			notified = append(notified, string(oldStatus)+">"+string(result.Status))
+		}
+
+		statuses.WriteString(letters[result.Status])
+		previous = result
+	}
+	return statuses.String(), notified
+}
+
+func TestConfirmStatus(t *testing.T) {
+	endpoint := &Endpoint{FailureThreshold: 2, SuccessThreshold: 2}
+
+	tests := []struct {
+		sequence string
+		blocked  bool
+		statuses string
+		notified string
+	}{
+		// Checks during maintenance are confirmed, the end of the window is compared to the
+		// confirmed status
+		{"GggRG", false, "GMMGG", ""},
+		{"GgrG", false, "GMMG", ""},
+		{"GgrR", false, "GMMR", "maintenance>red"},
+		{"GgrrR", false, "GMMMR", "maintenance>red"},
+		{"RRrgG", false, "RRMMG", ""},
+		{"RRgY", false, "RRMR", "maintenance>red"},
+		{"Mg", false, "MM", ""},
+
+		// Blocked endpoints are red until better checks are confirmed
+		{"GRRGG", true, "GGBBG", ""},
+		{"GRRYY", true, "GGBBY", "blocked>yellow"},
+		{"GRRrrY", true, "GGBMMB", ""},
+	}
+
+	for _, test := range tests {
+		statuses, notified := runConfirmation(endpoint, test.sequence, test.blocked)
+		if statuses != test.statuses {
+			t.Errorf("%s: expected %s, got %s", test.sequence, test.statuses, statuses)
+		}
+		if strings.Join(notified, ",") != test.notified {
+			t.Errorf("%s: expected notifications %q, got %q", test.sequence, test.notified, strings.Join(notified, ","))
+		}
+	}
+}
+
+func TestConfirmThresholds(t *testing.T) {
+	tests := []struct {
+		failureThreshold int
+		successThreshold int
+		sequence         string
+		statuses         string
+	}{
+		{1, 1, "GRGYG", "GRGYG"},
+		{3, 1, "GRRRG", "GGGRG"},
+		{3, 1, "GRRGRRR", "GGGGGGR"},
+		{2, 3, "RGGRGGG", "RRRRRRG"},
+		{2, 1, "GYYR", "GGYY"},
+		// The count starts again when the observed status changes
+		{2, 1, "GYRR", "GGGR"},
+		{2, 2, "RYGG", "RRRG"},
+		{3, 1, "GYRYRR", "GGGGGG"},
+		// Inactive results are not confirmed
+		{2, 2, "GIRR", "GIRR"},
+	}
+
+	for _, test := range tests {
+		endpoint := &Endpoint{FailureThreshold: test.failureThreshold, SuccessThreshold: test.successThreshold}
+		statuses, _ := runConfirmation(endpoint, test.sequence, false)
+		if statuses != test.statuses {
+			t.Errorf("%d/%d %s: expected %s, got %s", test.failureThreshold, test.successThreshold, test.sequence, test.statuses, statuses)
+		}
+	}
+
+	// The pending status and count are shown with the result
+	endpoint := &Endpoint{FailureThreshold: 3, SuccessThreshold: 1}
+	previous := &Result{Status: STATUS_GREEN}
+	for count := 1; count < 3; count++ {
+		result := &Result{Status: STATUS_RED}
+		confirmStatus(endpoint, previous, result)
+		if result.Status != STATUS_GREEN || result.PendingStatus != STATUS_RED || result.PendingCount != count {
+			t.Errorf("expected green with red pending %d times, got %s with %s pending %d times", count, result.Status, result.PendingStatus, result.PendingCount)
+		}
+		previous = result
+	}
+}
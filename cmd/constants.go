@@ -5,15 +5,26 @@ const (
 	EXIT_CLI_ARGS     = 1
 	EXIT_PARSE_CONFIG = 2
 	EXIT_CACHE_FILE   = 4
+	EXIT_HISTORY_FILE = 5
+	EXIT_STATE_FILE   = 6
 )
 
 type Status string
 
 const (
-	STATUS_GREEN    Status = "green"
-	STATUS_YELLOW   Status = "yellow"
-	STATUS_RED      Status = "red"
-	STATUS_INACTIVE Status = "grey"
+	STATUS_GREEN       Status = "green"
+	STATUS_YELLOW      Status = "yellow"
+	STATUS_RED         Status = "red"
+	STATUS_INACTIVE    Status = "grey"
+	STATUS_MAINTENANCE Status = "maintenance"
+	STATUS_BLOCKED     Status = "blocked"
+)
+
+const (
+	AGGREGATION_DEFAULT   = "default"
+	AGGREGATION_WORST     = "worst"
+	AGGREGATION_MAJORITY  = "majority"
+	AGGREGATION_THRESHOLD = "threshold"
 )
 
 const (
@@ -21,3 +32,31 @@ const (
 	AUTH_TYPE_CERT      = "client-cert"
 	AUTH_TYPE_CERT_INFO = "client-cert-info"
 )
+
+const (
+	TARGET_AUTH_BASIC  = "basic"
+	TARGET_AUTH_BEARER = "bearer"
+	TARGET_AUTH_OAUTH2 = "oauth2"
+)
+
+const (
+	JSON_OP_EQUALS       = "equals"
+	JSON_OP_NOT_EQUALS   = "not-equals"
+	JSON_OP_CONTAINS     = "contains"
+	JSON_OP_GREATER_THAN = "greater-than"
+	JSON_OP_LESS_THAN    = "less-than"
+	JSON_OP_REGEX        = "regex"
+	JSON_OP_EXISTS       = "exists"
+)
+
+const (
+	NOTIFICATION_TYPE_ENDPOINT = "endpoint"
+	NOTIFICATION_TYPE_GROUP    = "group"
+)
+
+const (
+	EVENT_TYPE_RESULT = "result"
+	EVENT_TYPE_CONFIG = "config"
+	EVENT_TYPE_ACK    = "ack"
+	EVENT_TYPE_GROUP  = "group"
+)
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CronSchedule is a parsed cron expression with the five fields minute, hour, day of month, month
+// and day of week. Fields support "*", lists ("1,15"), ranges ("1-5"), steps ("*/10", "0-30/5")
+// and names for months and days of week ("JAN", "MON"). Additionally the macros "@yearly",
+// "@monthly", "@weekly", "@daily" and "@hourly" are supported. As in classic cron, if both day of
+// month and day of week are restricted, a time matches if either of them matches.
+type CronSchedule struct {
+	expression string
+	minutes    uint64
+	hours      uint64
+	days       uint64
+	months     uint64
+	weekdays   uint64
+	anyDay     bool
+	anyWeekday bool
+}
+
+type cronField struct {
+	min   int
+	max   int
+	names []string
+}
+
+var (
+	cronMinutes  = cronField{min: 0, max: 59}
+	cronHours    = cronField{min: 0, max: 23}
+	cronDays     = cronField{min: 1, max: 31}
+	cronMonths   = cronField{min: 1, max: 12, names: []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}}
+	cronWeekdays = cronField{min: 0, max: 7, names: []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}}
+)
+
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+func ParseCron(expression string) (*CronSchedule, error) {
+	fieldExpression := strings.TrimSpace(expression)
+	if macro, ok := cronMacros[strings.ToLower(fieldExpression)]; ok {
+		fieldExpression = macro
+	}
+
+	fields := strings.Fields(fieldExpression)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression %q must have 5 fields", expression)
+	}
+
+	c := &CronSchedule{
+		expression: expression,
+		anyDay:     fields[2] == "*",
+		anyWeekday: fields[4] == "*",
+	}
+
+	var err error
+	for i, target := range []*uint64{&c.minutes, &c.hours, &c.days, &c.months, &c.weekdays} {
+		field := []cronField{cronMinutes, cronHours, cronDays, cronMonths, cronWeekdays}[i]
+		*target, err = field.parse(fields[i])
+		if err != nil {
+			return nil, fmt.Errorf("cron expression %q: %s", expression, err.Error())
+		}
+	}
+
+	// Sunday can be written as 0 or 7
+	if c.weekdays&(1<<7) != 0 {
+		c.weekdays |= 1
+	}
+
+	return c, nil
+}
+
+// parse returns a bit set of all values matched by the field expression
+func (f cronField) parse(expression string) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(expression, ",") {
+		rangeExpression, stepExpression, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			var err error
+			step, err = strconv.Atoi(stepExpression)
+			if err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step %q", stepExpression)
+			}
+		}
+
+		var first, last int
+		if rangeExpression == "*" {
+			first, last = f.min, f.max
+		} else {
+			firstExpression, lastExpression, isRange := strings.Cut(rangeExpression, "-")
+			var err error
+			first, err = f.value(firstExpression)
+			if err != nil {
+				return 0, err
+			}
+			last = first
+			if isRange {
+				last, err = f.value(lastExpression)
+				if err != nil {
+					return 0, err
+				}
+			} else if hasStep {
+				last = f.max
+			}
+		}
+		if first > last {
+			return 0, fmt.Errorf("invalid range %q", rangeExpression)
+		}
+
+		for v := first; v <= last; v += step {
+			bits |= 1 << v
+		}
+	}
+	return bits, nil
+}
+
+func (f cronField) value(expression string) (int, error) {
+	for i, name := range f.names {
+		if strings.EqualFold(expression, name) {
+			return i + f.min, nil
+		}
+	}
+
+	v, err := strconv.Atoi(expression)
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf("invalid value %q", expression)
+	}
+	return v, nil
+}
+
+// Next returns the first time after the given time that matches the schedule or the zero time if
+// there is none within the next five years
+func (c *CronSchedule) Next(after time.Time) time.Time {
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	limit := after.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if c.months&(1<<int(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !c.matchesDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if c.hours&(1<<t.Hour()) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if c.minutes&(1<<t.Minute()) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+func (c *CronSchedule) matchesDay(t time.Time) bool {
+	day := c.days&(1<<t.Day()) != 0
+	weekday := c.weekdays&(1<<int(t.Weekday())) != 0
+	if c.anyDay || c.anyWeekday {
+		return day && weekday
+	}
+	return day || weekday
+}
+
+func (c *CronSchedule) String() string {
+	return c.expression
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCronErrors(t *testing.T) {
+	tests := []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"*/x * * * *",
+		"30-10 * * * *",
+		"* * * foo *",
+		"@every",
+	}
+
+	for _, expression := range tests {
+		if _, err := ParseCron(expression); err == nil {
+			t.Errorf("%q: expected an error", expression)
+		}
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("time zone data not available: %s", err.Error())
+	}
+	at := func(value string) time.Time {
+		parsed, err := time.ParseInLocation("2006-01-02 15:04", value, berlin)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return parsed
+	}
+
+	// 2026-03-01 is a Sunday
+	tests := []struct {
+		expression string
+		after      string
+		expected   []string
+	}{
+		{"* * * * *", "2026-03-02 10:15", []string{"2026-03-02 10:16", "2026-03-02 10:17"}},
+		{"*/15 * * * *", "2026-03-02 10:15", []string{"2026-03-02 10:30", "2026-03-02 10:45", "2026-03-02 11:00"}},
+		{"5-20/5 8 * * *", "2026-03-02 08:12", []string{"2026-03-02 08:15", "2026-03-02 08:20", "2026-03-03 08:05"}},
+		{"0 9,17 * * *", "2026-03-02 09:00", []string{"2026-03-02 17:00", "2026-03-03 09:00"}},
+		{"@hourly", "2026-03-02 10:59", []string{"2026-03-02 11:00", "2026-03-02 12:00"}},
+		{"@daily", "2026-03-02 10:00", []string{"2026-03-03 00:00"}},
+		{"@monthly", "2026-03-02 10:00", []string{"2026-04-01 00:00", "2026-05-01 00:00"}},
+		{"@yearly", "2026-03-02 10:00", []string{"2027-01-01 00:00"}},
+		{"0 0 31 * *", "2026-03-31 00:00", []string{"2026-05-31 00:00", "2026-07-31 00:00"}},
+		{"0 0 29 feb *", "2026-03-02 10:00", []string{"2028-02-29 00:00"}},
+		{"0 0 30 feb *", "2026-03-02 10:00", []string{"0001-01-01 00:00"}},
+
+		// Names, Sunday as 0 and 7
+		{"0 12 * * MON-FRI", "2026-03-06 12:00", []string{"2026-03-09 12:00"}},
+		{"0 12 * * 0", "2026-03-02 10:00", []string{"2026-03-08 12:00"}},
+		{"0 12 * * 7", "2026-03-02 10:00", []string{"2026-03-08 12:00"}},
+		{"0 12 * * @weekly", "2026-03-02 10:00", nil},
+		{"@weekly", "2026-03-02 10:00", []string{"2026-03-08 00:00"}},
+		{"0 12 * jan,Dec sun", "2026-03-02 10:00", []string{"2026-12-06 12:00"}},
+
+		// Day of month and day of week: either has to match if both are restricted
+		{"0 0 13 * 5", "2026-03-01 00:00", []string{"2026-03-06 00:00", "2026-03-13 00:00", "2026-03-20 00:00"}},
+		{"0 0 1 * mon", "2026-03-01 00:00", []string{"2026-03-02 00:00", "2026-03-09 00:00"}},
+		{"0 0 * * mon", "2026-03-01 00:00", []string{"2026-03-02 00:00", "2026-03-09 00:00"}},
+		{"0 0 1 * *", "2026-03-01 00:00", []string{"2026-04-01 00:00"}},
+
+		// Daylight saving time: 2026-03-29 02:00 does not exist (the time is skipped), 2026-10-25
+		// 02:00-03:00 occurs twice (daily times once, see below for hourly)
+		{"30 2 * * *", "2026-03-28 12:00", []string{"2026-03-30 02:30"}},
+		{"0 * * * *", "2026-03-29 00:30", []string{"2026-03-29 01:00", "2026-03-29 03:00", "2026-03-29 04:00"}},
+		{"30 2 * * *", "2026-10-24 12:00", []string{"2026-10-25 02:30", "2026-10-26 02:30"}},
+		{"0 3 * * *", "2026-10-25 01:00", []string{"2026-10-25 03:00"}},
+	}
+
+	for _, test := range tests {
+		cron, err := ParseCron(test.expression)
+		if test.expected == nil {
+			if err == nil {
+				t.Errorf("%q: expected an error", test.expression)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: %s", test.expression, err.Error())
+			continue
+		}
+
+		after := at(test.after)
+		for _, expected := range test.expected {
+			next := cron.Next(after)
+			if expected == "0001-01-01 00:00" {
+				if !next.IsZero() {
+					t.Errorf("%q after %s: expected no time, got %s", test.expression, after, next)
+				}
+				break
+			}
+			if !next.Equal(at(expected)) {
+				t.Errorf("%q after %s: expected %s, got %s", test.expression, after, expected, next)
+				break
+			}
+			after = next
+		}
+	}
+}
+
+func TestCronNextRepeatedHour(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("time zone data not available: %s", err.Error())
+	}
+
+	// Hourly schedules run every real hour, also in the hour that occurs twice
+	cron, _ := ParseCron("0 * * * *")
+	next := time.Date(2026, 10, 24, 23, 30, 0, 0, time.UTC).In(berlin)
+	for _, expected := range []int{0, 1, 2, 3} {
+		next = cron.Next(next)
+		if !next.Equal(time.Date(2026, 10, 25, expected, 0, 0, 0, time.UTC)) {
+			t.Errorf("expected %02d:00 UTC, got %s", expected, next.UTC())
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// endpointRef is a resolved reference to an endpoint
+type endpointRef struct {
+	group    *Group
+	endpoint *Endpoint
+}
+
+func (r endpointRef) String() string {
+	return r.group.Name + "/" + r.endpoint.Name
+}
+
+// resolveDependencies resolves the dependsOn references of all endpoints (including the ones of
+// their groups) and makes sure that there are no cycles. References have the form "Group/Endpoint"
+// or "Endpoint" for endpoints of the same group.
+func resolveDependencies(config *Configuration) error {
+	for _, group := range config.AllGroups() {
+		for _, endpoint := range group.Endpoints {
+			endpoint.dependencies = make([]endpointRef, 0)
+			references := append(append(StringList{}, group.DependsOn...), endpoint.DependsOn...)
+			for _, reference := range references {
+				dependency, err := findEndpoint(config, group, reference)
+				if err != nil {
+					return fmt.Errorf("endpoint %s in group %s: dependsOn: %s", endpoint.Name, group.Name, err.Error())
+				}
+				if dependency.endpoint == endpoint {
+					continue // Group dependencies on own endpoints
+				}
+				endpoint.dependencies = append(endpoint.dependencies, dependency)
+			}
+		}
+	}
+
+	visited := make(map[*Endpoint]bool)
+	for _, group := range config.AllGroups() {
+		for _, endpoint := range group.Endpoints {
+			err := checkDependencyCycle(endpointRef{group: group, endpoint: endpoint}, visited, make([]endpointRef, 0))
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func findEndpoint(config *Configuration, group *Group, reference string) (endpointRef, error) {
+	groupName, endpointName, ok := strings.Cut(reference, "/")
+	if !ok {
+		groupName, endpointName = group.Name, reference
+	}
+
+	for _, g := range config.AllGroups() {
+		if g.Name != groupName {
+			continue
+		}
+		for _, e := range g.Endpoints {
+			if e.Name == endpointName {
+				return endpointRef{group: g, endpoint: e}, nil
+			}
+		}
+	}
+	return endpointRef{}, fmt.Errorf("endpoint %s not found", reference)
+}
+
+// checkDependencyCycle returns an error if one of the dependencies (transitively) depends on the
+// endpoint itself. path contains the endpoints that led to this one.
+func checkDependencyCycle(ref endpointRef, visited map[*Endpoint]bool, path []endpointRef) error {
+	for i, p := range path {
+		if p.endpoint == ref.endpoint {
+			cycle := make([]string, 0, len(path)-i+1)
+			for _, c := range append(path[i:], ref) {
+				cycle = append(cycle, c.String())
+			}
+			return fmt.Errorf("dependency cycle: %s", strings.Join(cycle, " -> "))
+		}
+	}
+	if visited[ref.endpoint] {
+		return nil
+	}
+
+	path = append(path, ref)
+	for _, dependency := range ref.endpoint.dependencies {
+		err := checkDependencyCycle(dependency, visited, path)
+		if err != nil {
+			return err
+		}
+	}
+	visited[ref.endpoint] = true
+	return nil
+}
+
+// blockedBy returns the chain of dependencies that causes the endpoint to fail (the first one is
+// a direct dependency, the last one is red) or nil if all dependencies are fine. Must be called
+// with resultsMutex locked.
+func (s *Server) blockedBy(endpoint *Endpoint) []string {
+	for _, dependency := range endpoint.dependencies {
+		result, ok := s.results[dependency.endpoint.ID]
+		if !ok {
+			continue
+		}
+		switch result.Status {
+		case STATUS_RED:
+			return []string{dependency.String()}
+		case STATUS_BLOCKED:
+			return append([]string{dependency.String()}, result.BlockedBy...)
+		}
+	}
+	return nil
+}
+
+// recheckDependents checks all endpoints that directly depend on the endpoint again, so that they
+// are blocked or unblocked right away
+func (s *Server) recheckDependents(endpoint *Endpoint) {
+	for _, group := range s.config().AllGroups() {
+		for _, e := range group.Endpoints {
+			for _, dependency := range e.dependencies {
+				if dependency.endpoint == endpoint {
+					s.recheck(group, e.Name)
+					break
+				}
+			}
+		}
+	}
+}
+
+// isDown returns whether dependents of an endpoint with this status are blocked
+func isDown(status Status) bool {
+	return status == STATUS_RED || status == STATUS_BLOCKED
+}
@@ -0,0 +1,314 @@
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	dnsClassIN       = 1
+	dnsHeaderLength  = 12
+	dnsMaxUDPSize    = 512
+	dnsFlagResponse  = 1 << 15
+	dnsFlagTruncated = 1 << 9
+	dnsFlagRecursion = 1 << 8
+	dnsRcodeNXDomain = 3
+
+	dnsDefaultNameserver = "127.0.0.1:53"
+	dnsResolvConf        = "/etc/resolv.conf"
+)
+
+const (
+	dnsTypeA     uint16 = 1
+	dnsTypeNS    uint16 = 2
+	dnsTypeCNAME uint16 = 5
+	dnsTypeMX    uint16 = 15
+	dnsTypeTXT   uint16 = 16
+	dnsTypeAAAA  uint16 = 28
+	dnsTypeSRV   uint16 = 33
+)
+
+var dnsTypeCodes = map[string]uint16{"A": dnsTypeA, "NS": dnsTypeNS, "CNAME": dnsTypeCNAME, "MX": dnsTypeMX, "TXT": dnsTypeTXT, "AAAA": dnsTypeAAAA, "SRV": dnsTypeSRV}
+
+var errDNSMessage = errors.New("invalid DNS message")
+
+// DNSQuery sends a query for the record type of name directly to the server (host:port) and
+// returns the answers of that type. The name is always treated as fully qualified, neither the
+// hosts file nor search domains are used. If the UDP response is truncated, the query is repeated
+// via TCP. MX records are formatted as "preference host", SRV records as
+// "priority weight port target".
+func DNSQuery(ctx context.Context, server string, recordType string, name string) ([]string, error) {
+	qtype, ok := dnsTypeCodes[recordType]
+	if !ok {
+		return nil, fmt.Errorf("unsupported record type %s", recordType)
+	}
+
+	id := uint16(rand.Uint32())
+	query, err := dnsQueryMessage(id, name, qtype)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := dnsExchange(ctx, "udp", server, id, query)
+	if err == nil && binary.BigEndian.Uint16(response[2:])&dnsFlagTruncated != 0 {
+		response, err = dnsExchange(ctx, "tcp", server, id, query)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return parseDNSResponse(response, id, qtype)
+}
+
+// systemNameserver returns the first nameserver of the system configuration
+func systemNameserver() string {
+	data, err := os.ReadFile(dnsResolvConf)
+	if err != nil {
+		return dnsDefaultNameserver
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && fields[0] == "nameserver" {
+			return net.JoinHostPort(fields[1], "53")
+		}
+	}
+	return dnsDefaultNameserver
+}
+
+// dnsQueryMessage creates a query with recursion desired for the (fully qualified) name
+func dnsQueryMessage(id uint16, name string, qtype uint16) ([]byte, error) {
+	msg := make([]byte, dnsHeaderLength, dnsHeaderLength+len(name)+6)
+	binary.BigEndian.PutUint16(msg[0:], id)
+	binary.BigEndian.PutUint16(msg[2:], dnsFlagRecursion)
+	binary.BigEndian.PutUint16(msg[4:], 1) // Questions
+
+	msg, err := appendDNSName(msg, name)
+	if err != nil {
+		return nil, err
+	}
+	msg = binary.BigEndian.AppendUint16(msg, qtype)
+	msg = binary.BigEndian.AppendUint16(msg, dnsClassIN)
+	return msg, nil
+}
+
+func appendDNSName(msg []byte, name string) ([]byte, error) {
+	name = strings.TrimSuffix(name, ".")
+	if len(name) > 253 {
+		return nil, fmt.Errorf("name %s is too long", name)
+	}
+	if name != "" {
+		for _, label := range strings.Split(name, ".") {
+			if len(label) == 0 || len(label) > 63 {
+				return nil, fmt.Errorf("name %s contains an invalid label", name)
+			}
+			msg = append(msg, byte(len(label)))
+			msg = append(msg, label...)
+		}
+	}
+	return append(msg, 0), nil
+}
+
+// dnsExchange sends the query and waits for the response with the same ID
+func dnsExchange(ctx context.Context, network string, server string, id uint16, query []byte) ([]byte, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, network, server)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = conn.SetDeadline(time.Now())
+		case <-done:
+		}
+	}()
+
+	if network == "tcp" {
+		_, err = conn.Write(append(binary.BigEndian.AppendUint16(nil, uint16(len(query))), query...))
+		if err != nil {
+			return nil, err
+		}
+		length := make([]byte, 2)
+		if _, err = io.ReadFull(conn, length); err != nil {
+			return nil, err
+		}
+		response := make([]byte, binary.BigEndian.Uint16(length))
+		if _, err = io.ReadFull(conn, response); err != nil {
+			return nil, err
+		}
+		if len(response) < dnsHeaderLength || binary.BigEndian.Uint16(response) != id {
+			return nil, errDNSMessage
+		}
+		return response, nil
+	}
+
+	_, err = conn.Write(query)
+	if err != nil {
+		return nil, err
+	}
+	buffer := make([]byte, dnsMaxUDPSize)
+	for {
+		n, err := conn.Read(buffer)
+		if err != nil {
+			return nil, err
+		}
+		// Ignore late responses to other queries
+		if n >= dnsHeaderLength && binary.BigEndian.Uint16(buffer) == id {
+			return buffer[:n], nil
+		}
+	}
+}
+
+// parseDNSResponse returns the answers of the queried type. Other records (e.g. the CNAME records
+// leading to the A records) are skipped.
+func parseDNSResponse(msg []byte, id uint16, qtype uint16) ([]string, error) {
+	if len(msg) < dnsHeaderLength || binary.BigEndian.Uint16(msg) != id {
+		return nil, errDNSMessage
+	}
+	flags := binary.BigEndian.Uint16(msg[2:])
+	if flags&dnsFlagResponse == 0 {
+		return nil, errDNSMessage
+	}
+	switch rcode := flags & 0x0f; rcode {
+	case 0:
+		// No error
+	case dnsRcodeNXDomain:
+		return nil, fmt.Errorf("name not found (NXDOMAIN)")
+	default:
+		return nil, fmt.Errorf("query failed with response code %d", rcode)
+	}
+
+	questions := int(binary.BigEndian.Uint16(msg[4:]))
+	answerCount := int(binary.BigEndian.Uint16(msg[6:]))
+	offset := dnsHeaderLength
+	for i := 0; i < questions; i++ {
+		_, next, err := readDNSName(msg, offset)
+		if err != nil {
+			return nil, err
+		}
+		offset = next + 4
+	}
+
+	answers := make([]string, 0, answerCount)
+	for i := 0; i < answerCount; i++ {
+		_, next, err := readDNSName(msg, offset)
+		if err != nil {
+			return nil, err
+		}
+		if next+10 > len(msg) {
+			return nil, errDNSMessage
+		}
+		rtype := binary.BigEndian.Uint16(msg[next:])
+		length := int(binary.BigEndian.Uint16(msg[next+8:]))
+		start, end := next+10, next+10+length
+		if end > len(msg) {
+			return nil, errDNSMessage
+		}
+		offset = end
+
+		if rtype != qtype {
+			continue
+		}
+		answer, err := formatDNSRecord(msg, rtype, start, end)
+		if err != nil {
+			return nil, err
+		}
+		answers = append(answers, answer)
+	}
+	return answers, nil
+}
+
+func formatDNSRecord(msg []byte, rtype uint16, start int, end int) (string, error) {
+	data := msg[start:end]
+	switch rtype {
+	case dnsTypeA, dnsTypeAAAA:
+		if len(data) != net.IPv4len && len(data) != net.IPv6len {
+			return "", errDNSMessage
+		}
+		return net.IP(data).String(), nil
+
+	case dnsTypeCNAME, dnsTypeNS:
+		name, _, err := readDNSName(msg, start)
+		return name, err
+
+	case dnsTypeMX:
+		if len(data) < 3 {
+			return "", errDNSMessage
+		}
+		host, _, err := readDNSName(msg, start+2)
+		return fmt.Sprintf("%d %s", binary.BigEndian.Uint16(data), host), err
+
+	case dnsTypeSRV:
+		if len(data) < 7 {
+			return "", errDNSMessage
+		}
+		target, _, err := readDNSName(msg, start+6)
+		return fmt.Sprintf("%d %d %d %s", binary.BigEndian.Uint16(data), binary.BigEndian.Uint16(data[2:]), binary.BigEndian.Uint16(data[4:]), target), err
+
+	case dnsTypeTXT:
+		// The character strings of one record are joined
+		text := strings.Builder{}
+		for i := 0; i < len(data); {
+			length := int(data[i])
+			if i+1+length > len(data) {
+				return "", errDNSMessage
+			}
+			text.Write(data[i+1 : i+1+length])
+			i += 1 + length
+		}
+		return text.String(), nil
+	}
+	return "", errDNSMessage
+}
+
+// readDNSName reads the (possibly compressed) name at offset and returns it fully qualified and
+// the offset after it
+func readDNSName(msg []byte, offset int) (string, int, error) {
+	labels := make([]string, 0)
+	next := -1
+	for jumps := 0; ; {
+		if offset >= len(msg) {
+			return "", 0, errDNSMessage
+		}
+		length := int(msg[offset])
+		switch {
+		case length == 0:
+			if next == -1 {
+				next = offset + 1
+			}
+			return strings.Join(labels, ".") + ".", next, nil
+
+		case length&0xc0 == 0xc0:
+			if offset+1 >= len(msg) || jumps > 10 {
+				return "", 0, errDNSMessage
+			}
+			if next == -1 {
+				next = offset + 2
+			}
+			offset = int(binary.BigEndian.Uint16(msg[offset:]) & 0x3fff)
+			jumps++
+
+		case length > 63 || offset+1+length > len(msg):
+			return "", 0, errDNSMessage
+
+		default:
+			labels = append(labels, string(msg[offset+1:offset+1+length]))
+			offset += 1 + length
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	eventSubscriberBufferSize = 64
+	eventKeepAliveInterval    = 30 * time.Second
+)
+
+type Event struct {
+	Type string
+	Data any
+}
+
+// ResultEvent is published after every check. The body of the response is left out, since it can
+// be large and is only needed for the details, which request it via /api/read.
+type ResultEvent struct {
+	Key     string  `json:"key"`
+	Result  *Result `json:"result"`
+	HasBody bool    `json:"has_body"`
+}
+
+func NewResultEvent(key string, result *Result) ResultEvent {
+	stripped := *result
+	stripped.Body = nil
+	return ResultEvent{
+		Key:     key,
+		Result:  &stripped,
+		HasBody: len(result.Body) > 0,
+	}
+}
+
+type GroupEvent struct {
+	Name   string `json:"name"`
+	Status Status `json:"status"`
+}
+
+type ConfigEvent struct {
+	Version int64 `json:"version"`
+}
+
+// EventHub distributes events to all subscribers. Subscribers that cannot keep up are dropped,
+// which ends their stream so that the client reconnects and starts with a new snapshot.
+type EventHub struct {
+	mutex       sync.Mutex
+	subscribers map[chan Event]bool
+}
+
+func NewEventHub() *EventHub {
+	return &EventHub{
+		subscribers: make(map[chan Event]bool),
+	}
+}
+
+func (h *EventHub) Subscribe() chan Event {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	subscriber := make(chan Event, eventSubscriberBufferSize)
+	h.subscribers[subscriber] = true
+	return subscriber
+}
+
+func (h *EventHub) Unsubscribe(subscriber chan Event) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if h.subscribers[subscriber] {
+		delete(h.subscribers, subscriber)
+		close(subscriber)
+	}
+}
+
+func (h *EventHub) Publish(event Event) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	for subscriber := range h.subscribers {
+		select {
+		case subscriber <- event:
+		default:
+			outDebug("Dropping slow event subscriber\n")
+			delete(h.subscribers, subscriber)
+			close(subscriber)
+		}
+	}
+}
+
+// Close ends the streams of all subscribers
+func (h *EventHub) Close() {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	for subscriber := range h.subscribers {
+		delete(h.subscribers, subscriber)
+		close(subscriber)
+	}
+}
+
+// handleEvents streams a snapshot of all results followed by result and config changes as
+// server-sent events
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	controller := http.NewResponseController(w)
+
+	// The stream is open much longer than the write timeout of the server allows
+	err := controller.SetWriteDeadline(time.Time{})
+	if err != nil {
+		s.respond(w, r, Error{
+			Code:    500,
+			Message: "Streaming not supported",
+		})
+		return
+	}
+
+	subscriber := s.events.Subscribe()
+	defer s.events.Unsubscribe(subscriber)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+
+	snapshot, err := json.Marshal(s.respondReadAll())
+	if err != nil {
+		outError("Cannot create event snapshot: %s\n", err.Error())
+		return
+	}
+
+	_, err = fmt.Fprintf(w, "event: snapshot\ndata: %s\n\n", snapshot)
+	if err == nil {
+		err = controller.Flush()
+	}
+
+	keepAlive := time.NewTicker(eventKeepAliveInterval)
+	defer keepAlive.Stop()
+
+	for err == nil {
+		select {
+		case <-r.Context().Done():
+			return
+
+		case <-keepAlive.C:
+			_, err = fmt.Fprint(w, ": keep-alive\n\n")
+
+		case event, ok := <-subscriber:
+			if !ok {
+				return
+			}
+
+			var data []byte
+			data, err = json.Marshal(event.Data)
+			if err != nil {
+				outError("Cannot send %s event: %s\n", event.Type, err.Error())
+				return
+			}
+			_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data)
+		}
+
+		if err == nil {
+			err = controller.Flush()
+		}
+	}
+}
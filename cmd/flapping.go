@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+)
+
+const flappingDefaultWindow = 10
+
+// FlappingConfiguration defines when an endpoint is flapping: if more than threshold (a share
+// between 0 and 1) of the last window checks changed the status. Flapping ends when the share
+// drops below half of the threshold. Without threshold flapping detection is disabled.
+type FlappingConfiguration struct {
+	Window    int     `yaml:"window,omitempty" json:"window,omitempty"`
+	Threshold float64 `yaml:"threshold,omitempty" json:"threshold,omitempty"`
+}
+
+// prepareFlapping takes the flapping configuration of the group if the endpoint has none and
+// applies the defaults
+func (e *Endpoint) prepareFlapping(group *Group) error {
+	if e.Flapping.Threshold == 0 {
+		e.Flapping = group.Flapping
+	}
+
+	if e.Flapping.Threshold < 0 || e.Flapping.Threshold > 1 {
+		return fmt.Errorf("flapping.threshold must be between 0 and 1")
+	}
+	if e.Flapping.Window == 0 {
+		e.Flapping.Window = flappingDefaultWindow
+	}
+	if e.Flapping.Window < 2 {
+		return fmt.Errorf("flapping.window must be at least 2")
+	}
+	return nil
+}
+
+// detectFlapping adds the status of the result to the recent status of the previous result and
+// sets whether the endpoint is flapping. Results that are not green, yellow or red (e.g. blocked or
+// in maintenance) do not change the recent status and keep the flapping state of the previous one.
+func detectFlapping(endpoint *Endpoint, previous *Result, result *Result) {
+	if endpoint.Flapping.Threshold == 0 {
+		return
+	}
+
+	switch result.Status {
+	case STATUS_GREEN, STATUS_YELLOW, STATUS_RED:
+		// Counted
+	default:
+		if previous != nil {
+			result.recentStatus = previous.recentStatus
+			result.Flapping = previous.Flapping
+			result.stableStatus = previous.stableStatus
+		}
+		return
+	}
+
+	window := endpoint.Flapping.Window
+	recent := make([]Status, 0, window)
+	wasFlapping := false
+	if previous != nil {
+		recent = append(recent, previous.recentStatus...)
+		wasFlapping = previous.Flapping
+	}
+	recent = append(recent, result.Status)
+	if len(recent) > window {
+		recent = recent[len(recent)-window:]
+	}
+	result.recentStatus = recent
+
+	transitions := 0
+	for i := 1; i < len(recent); i++ {
+		if recent[i] != recent[i-1] {
+			transitions++
+		}
+	}
+	rate := float64(transitions) / float64(window-1)
+
+	if wasFlapping {
+		result.Flapping = rate >= endpoint.Flapping.Threshold/2
+	} else {
+		result.Flapping = rate > endpoint.Flapping.Threshold
+	}
+
+	// The last notified status is kept while flapping, so the status after it can be compared to it
+	switch {
+	case !result.Flapping:
+		result.stableStatus = ""
+	case wasFlapping:
+		result.stableStatus = previous.stableStatus
+	case previous != nil && !isTransition(previous.Status, result.Status):
+		result.stableStatus = previous.Status
+	default:
+		result.stableStatus = result.Status
+	}
+}
+
+// endpointTransition returns the status the result is compared to in a notification and whether
+// it is notified. Transitions are not notified while flapping; when flapping ends, the status is
+// compared to the last one notified before.
+func endpointTransition(previous *Result, result *Result) (Status, bool) {
+	switch {
+	case previous == nil || (previous.Flapping && result.Flapping):
+		return "", false
+	case previous.Flapping:
+		stable := previous.stableStatus
+		if stable == "" {
+			// Not known after a restart
+			stable = previous.Status
+		}
+		return stable, isTransition(stable, result.Status)
+	}
+	return previous.Status, isTransition(previous.Status, result.Status)
+}
+
+// isGroupFlapping returns whether an endpoint of the group or one of its subgroups is flapping.
+// Must be called with resultsMutex locked.
+func (s *Server) isGroupFlapping(group *Group) bool {
+	for _, endpoint := range group.Endpoints {
+		if result, ok := s.results[endpoint.ID]; ok && result.Flapping {
+			return true
+		}
+	}
+	for _, subgroup := range group.Groups {
+		if s.isGroupFlapping(subgroup) {
+			return true
+		}
+	}
+	return false
+}
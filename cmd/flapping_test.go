@@ -0,0 +1,130 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+var testStatusLetters = map[byte]Status{
+	'G': STATUS_GREEN,
+	'Y': STATUS_YELLOW,
+	'R': STATUS_RED,
+	'B': STATUS_BLOCKED,
+	'M': STATUS_MAINTENANCE,
+	'I': STATUS_INACTIVE,
+}
+
+// runFlapping checks the endpoint with the given sequence of status (as letters) and returns
+// whether it was flapping after each check (as "F" or "-") and the notified transitions
+func runFlapping(endpoint *Endpoint, sequence string) (string, []string) {
+	flapping := strings.Builder{}
+	notified := make([]string, 0)
+	var previous *Result
+	for i := 0; i < len(sequence); i++ {
+		result := &Result{Status: testStatusLetters[sequence[i]]}
+		detectFlapping(endpoint, previous, result)
+		if oldStatus, notify := endpointTransition(previous, result); notify {
+			notified = append(notified, string(oldStatus)+">"+string(result.Status))
+		}
+
+		if result.Flapping {
+			flapping.WriteString("F")
+		} else {
+			flapping.WriteString("-")
+		}
+		previous = result
+	}
+	return flapping.String(), notified
+}
+
+func TestDetectFlapping(t *testing.T) {
+	// Flapping with more than 2 of the 4 possible changes in the window, until there is none
+	endpoint := &Endpoint{Flapping: FlappingConfiguration{Threshold: 0.5, Window: 5}}
+
+	tests := []struct {
+		sequence string
+		flapping string
+	}{
+		{"GGGGGGGG", "--------"},
+		{"GRGGGRGG", "--------"},
+		{"GRGR", "---F"},
+		{"GRGRGGGGGG", "---FFFFF--"},
+		{"GYRYGGGGGG", "---FFFFF--"},
+		{"GRGRGRRRRRR", "---FFFFFF--"},
+		// Blocked, maintenance and inactive checks neither count nor end flapping
+		{"GRGRMMMGGGGG", "---FFFFFFFF-"},
+		{"GRGRBBGGGGG", "---FFFFFFF-"},
+		{"GRGIIGR", "------F"},
+		{"GRMGR", "----F"},
+	}
+
+	for _, test := range tests {
+		flapping, _ := runFlapping(endpoint, test.sequence)
+		if flapping != test.flapping {
+			t.Errorf("%s: expected %s, got %s", test.sequence, test.flapping, flapping)
+		}
+	}
+
+	// Without threshold flapping is never detected
+	if flapping, _ := runFlapping(&Endpoint{}, "GRGRGRGR"); flapping != "--------" {
+		t.Errorf("expected no flapping without threshold, got %s", flapping)
+	}
+}
+
+func TestFlappingWindows(t *testing.T) {
+	tests := []struct {
+		window    int
+		threshold float64
+		sequence  string
+		flapping  string
+	}{
+		// The share of changes is relative to the possible changes in the full window
+		{3, 0.5, "GRGGG", "--FF-"},
+		{3, 0.5, "GYGGG", "--FF-"},
+		{3, 1, "GRGRGR", "------"},
+		{2, 0.5, "GRRGG", "-F-F-"},
+		{10, 0.3, "GRGR", "---F"},
+		// Changes count as long as they are in the window
+		{10, 0.3, "GRGGGGR", "------F"},
+		{5, 0.5, "GRGGGGR", "-------"},
+		// Flapping ends when at most one change is left in the window
+		{10, 0.3, "GRGRGGGGGGGGG", "---FFFFFFFFF-"},
+	}
+
+	for _, test := range tests {
+		endpoint := &Endpoint{Flapping: FlappingConfiguration{Threshold: test.threshold, Window: test.window}}
+		flapping, _ := runFlapping(endpoint, test.sequence)
+		if flapping != test.flapping {
+			t.Errorf("%d/%.1f %s: expected %s, got %s", test.window, test.threshold, test.sequence, test.flapping, flapping)
+		}
+	}
+}
+
+func TestFlappingNotifications(t *testing.T) {
+	endpoint := &Endpoint{Flapping: FlappingConfiguration{Threshold: 0.5, Window: 5}}
+
+	tests := []struct {
+		sequence string
+		notified string
+	}{
+		{"GRGGG", "green>red,red>green"},
+		// The change that starts flapping is notified, the status after it only if it differs
+		{"GRGRGRGGGGG", "green>red,red>green,green>red,red>green"},
+		{"GRGRGRRRRRR", "green>red,red>green,green>red"},
+		{"GRGRGRYYYYY", "green>red,red>green,green>red,red>yellow"},
+		// A flapping endpoint that settles after maintenance or blocked checks
+		{"GRGRMMGGGGG", "green>red,red>green,green>red,red>green"},
+		{"GRGRBBRRRRR", "green>red,red>green,green>red"},
+		// Entering and leaving maintenance while not flapping
+		{"GMMG", ""},
+		{"GMMR", "maintenance>red"},
+		{"GBBR", "blocked>red"},
+	}
+
+	for _, test := range tests {
+		_, notified := runFlapping(endpoint, test.sequence)
+		if strings.Join(notified, ",") != test.notified {
+			t.Errorf("%s: expected %s, got %s", test.sequence, test.notified, strings.Join(notified, ","))
+		}
+	}
+}
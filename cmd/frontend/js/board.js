@@ -8,34 +8,191 @@ export default class Board {
 
         this._promiseConfig = this.request("config");
         this._promiseData = this.request("readAll");
+        this._promiseAcks = this.request("ack");
     }
 
     async render(domRoot) {
         this.domRoot = domRoot;
 
+        // The hash contains the path of the group that is shown, e.g. #/Production/Europe
+        this.path = this.pathFromHash();
+        window.addEventListener("hashchange", () => {
+            this.path = this.pathFromHash();
+            this.updateTiles();
+        });
+
         this.config = await this._promiseConfig;
-        this.data = await this._promiseData;
+        this.setData(await this._promiseData);
+        this.acks = (await this._promiseAcks).acknowledgements ?? [];
         this.updateTiles();
 
-        // Refresh every minute;
-        setInterval(async () => {
+        if (!this.connectEvents()) {
+            this.startPolling();
+        }
+    }
+
+    connectEvents() {
+        if (!window.EventSource) {
+            return false;
+        }
+
+        const source = new EventSource(this.apiUrl + "events");
+
+        source.addEventListener("snapshot", e => {
+            // (Re-)connected: No need to poll anymore
+            this.stopPolling();
+            this.setData(JSON.parse(e.data));
+            this.updateTiles();
+        });
+
+        source.addEventListener("result", e => {
+            const change = JSON.parse(e.data);
+            // The body is requested with the details
+            this.data[change.key] = { ...change.result, has_body: change.has_body };
+            this.updateTiles();
+        });
+
+        source.addEventListener("group", e => {
+            const change = JSON.parse(e.data);
+            this.groupStatus[change.name] = change.status;
+            this.updateTiles();
+        });
+
+        source.addEventListener("ack", e => {
+            this.acks = JSON.parse(e.data).acknowledgements ?? [];
+            this.updateTiles();
+        });
+
+        source.addEventListener("config", e => {
+            const change = JSON.parse(e.data);
+            if (String(change.version) !== this.configVersion) {
+                this.configVersion = String(change.version);
+                this.reloadConfig();
+            }
+        });
+
+        source.addEventListener("error", () => {
+            // Poll until the browser reconnects. If the connection is closed for good, try again later
+            this.startPolling();
+            if (source.readyState === EventSource.CLOSED) {
+                setTimeout(() => this.connectEvents(), this.config.refresh_interval * 1000);
+            }
+        });
+
+        return true;
+    }
+
+    stopPolling() {
+        clearInterval(this._pollInterval);
+        this._pollInterval = null;
+    }
+
+    startPolling() {
+        if (this._pollInterval) {
+            return;
+        }
+        this._pollInterval = setInterval(async () => {
             this._promiseData = this.request("readAll");
-            this.data = await this._promiseData;
+            this._promiseAcks = this.request("ack");
+            this.setData(await this._promiseData);
+            this.acks = (await this._promiseAcks).acknowledgements ?? [];
             this.updateTiles();
         }, this.config.refresh_interval * 500); // Request more often than backend refreshes
     }
 
+    setData(data) {
+        this.data = data.endpoints ?? {};
+        this.groupStatus = data.groups ?? {};
+    }
+
+    async reloadConfig() {
+        this._promiseConfig = this.request("config");
+        this.config = await this._promiseConfig;
+
+        // Groups might have been removed or renamed, so all tiles are created again
+        clear(this.domRoot.querySelector(".active"));
+        clear(this.domRoot.querySelector(".inactive"));
+
+        this.updateTiles();
+        if (this._pollInterval) {
+            // Restart with the new interval
+            this.stopPolling();
+            this.startPolling();
+        }
+    }
 
     async request(url) {
         const response = await fetch(this.apiUrl + url);
+
+        // The server configuration has been reloaded
+        const configVersion = response.headers.get("X-Config-Version");
+        if (configVersion && this.configVersion && configVersion !== this.configVersion) {
+            setTimeout(() => this.reloadConfig(), 0);
+        }
+        this.configVersion = configVersion ?? this.configVersion;
+
         return response.json();
     }
 
+    pathFromHash() {
+        return location.hash.replace(/^#\/?/, "").split("/").filter(name => name).map(decodeURIComponent);
+    }
+
+    // currentGroups returns the groups along the current path. Missing groups (e.g. after a
+    // configuration reload) end the path.
+    currentGroups() {
+        const groups = [];
+        let level = this.config.groups;
+        for (const name of this.path) {
+            const group = (level ?? []).find(g => g.name === name);
+            if (!group) {
+                break;
+            }
+            groups.push(group);
+            level = group.groups;
+        }
+        return groups;
+    }
+
+    updateBreadcrumbs(currentGroups) {
+        const breadcrumbs = document.querySelector(".breadcrumbs");
+        clear(breadcrumbs);
+        if (currentGroups.length === 0) {
+            return;
+        }
+
+        breadcrumbs.append(d({ type: "a", textContent: this.config.title || "Overview", attributes: { href: "#/" } }));
+        currentGroups.forEach((group, i) => {
+            const path = currentGroups.slice(0, i + 1).map(g => encodeURIComponent(g.name)).join("/");
+            breadcrumbs.append(" › ", d({
+                type: "a",
+                classes: [`status_${this.groupStatus?.[group.name] ?? "grey"}`],
+                textContent: group.name,
+                attributes: { href: `#/${path}` }
+            }));
+        });
+    }
+
     updateTiles() {
         document.querySelector("title").textContent = this.config.title;
         document.querySelector(".mainTitle").textContent = this.config.title;
 
-        for (const group of this.config.groups) {
+        const currentGroups = this.currentGroups();
+        const current = currentGroups[currentGroups.length - 1];
+        const level = currentGroups.map(g => g.name).join("/");
+        if (level !== this._level) {
+            // Drilled up or down: Only the tiles of the new level are shown
+            this._level = level;
+            clear(this.domRoot.querySelector(".active"));
+            clear(this.domRoot.querySelector(".inactive"));
+        }
+        this.updateBreadcrumbs(currentGroups);
+
+        if (current?.endpoints?.length > 0) {
+            // The endpoints of the group itself
+            this.updateTile(current, true);
+        }
+        for (const group of current ? current.groups ?? [] : this.config.groups) {
             this.updateTile(group);
         }
         setTimeout(() => {
@@ -107,11 +264,11 @@ export default class Board {
         }
     }
 
-    updateTile(group) {
+    updateTile(group, current = false) {
         const activeGrid = document.querySelector(".overview .active");
         const inactiveGrid = document.querySelector(".overview .inactive");
 
-        const tile = this.groupTile(group)
+        const tile = this.groupTile(group, current)
 
         const parent =  group.inactive ? inactiveGrid : activeGrid;
         let tiles = parent.querySelector(`.tiles.category_${group.category}`);
@@ -125,45 +282,86 @@ export default class Board {
         const statusDots = tile.querySelector(".dots");
         clear(statusDots);
 
-        const status = {
-            "grey": 0,
-            "green": 0,
-            "yellow": 0,
-            "red": 0
-        };
-
-        for (const endpoint of group.endpoints) {
+        for (const endpoint of group.endpoints ?? []) {
             // const url = new URL(endpoint.url, group.url || undefined);
 
-            let st = this.data?.[endpoint.url]?.status;
+            let st = this.data?.[endpoint.id]?.status;
             if (!st) {
                 // Data not available yet
                 st = "grey";
             }
-            status[st]++;
 
+            const pending = this.data?.[endpoint.id]?.pending_status;
+            const flapping = this.data?.[endpoint.id]?.flapping;
+            const ack = this.ackFor(group, endpoint);
             const statusDot = d({
-                classes: ["dot", `status_${st}`],
+                classes: ["dot", `status_${st}`].concat(pending ? [`pending_${pending}`] : [], flapping ? ["flapping"] : [], ack ? ["acknowledged"] : []),
                 attributes: {
                     title: `${endpoint.name} status: ${st}`
+                        + (pending ? ` (${this.pendingText(endpoint, this.data[endpoint.id])})` : "")
+                        + (flapping ? " (flapping)" : "")
+                        + (st === "blocked" ? ` (${this.dependencyText(group, endpoint, this.data[endpoint.id])})` : "")
+                        + (ack ? ` (${this.ackText(ack)})` : "")
                 }
             });
             statusDots.append(statusDot)
         }
 
+        for (const subgroup of current ? [] : group.groups ?? []) {
+            const st = this.groupStatus?.[subgroup.name] ?? "grey";
+            statusDots.append(d({
+                classes: ["dot", "subgroup", `status_${st}`],
+                attributes: {
+                    title: `${subgroup.name} status: ${st}`
+                }
+            }));
+        }
 
-        if (group.forced_status) {
-            tile.classList.add(`status_${group.forced_status}`);
-        }  else if (group.inactive) {
-            tile.classList.toggle(`status_grey`, group.inactive);
-        } else {
-            tile.classList.toggle(`status_red`, status.red > status.green);
-            tile.classList.toggle(`status_yellow`, status.red > 0 || status.yellow > 0);
-            tile.classList.toggle(`status_green`, status.red === 0 && status.yellow === 0);
+
+        const groupAck = this.ackFor(group);
+        const endpointAcks = (group.endpoints ?? []).filter(endpoint => this.ackFor(group, endpoint)).length;
+        tile.querySelector(".ack").textContent = groupAck ? this.ackText(groupAck)
+            : endpointAcks > 0 ? `${endpointAcks} acknowledged` : "";
+
+        // The group status is computed by the server, so that it matches the notifications
+        const groupStatus = this.groupStatus?.[group.name] ?? "grey";
+        for (const st of ["grey", "green", "yellow", "red", "maintenance"]) {
+            tile.classList.toggle(`status_${st}`, st === groupStatus);
+        }
+    }
+
+    pendingText(endpoint, result) {
+        const worse = ["green", "yellow", "red"].indexOf(result.pending_status) > ["green", "yellow", "red"].indexOf(result.status);
+        const threshold = (worse ? endpoint.failureThreshold : endpoint.successThreshold) ?? 1;
+        return `${result.pending_status}, not yet confirmed (${result.pending_count}/${threshold})`;
+    }
+
+    ackFor(group, endpoint) {
+        if (endpoint) {
+            return (this.acks ?? []).find(ack => ack.id === endpoint.id);
+        }
+        return (this.acks ?? []).find(ack => ack.group === group.name && !ack.endpoint);
+    }
+
+    ackText(ack) {
+        return `acknowledged by ${ack.author || "unknown"}` + (ack.comment ? `: ${ack.comment}` : "");
+    }
+
+    dependencyText(group, endpoint, result) {
+        if (result?.blocked_by?.length > 0) {
+            return `blocked by upstream: ${result.blocked_by.join(" → ")}`;
         }
+        const dependsOn = (group.dependsOn ?? []).concat(endpoint.dependsOn ?? []).filter(name => name !== endpoint.name && name !== `${group.name}/${endpoint.name}`);
+        return dependsOn.length > 0 ? `depends on ${dependsOn.join(", ")}` : "";
     }
 
-    groupTile(group) {
+    maintenanceText(window) {
+        const until = window.end && !window.schedule ? ` until ${new Date(window.end).toLocaleString()}` : "";
+        const author = window.author ? ` by ${window.author}` : "";
+        return `maintenance${until}${author}` + (window.reason ? `: ${window.reason}` : "");
+    }
+
+    groupTile(group, current) {
         const groupId = group.name.replaceAll(/[^a-z0-9_]/ig, "_");
         let tile = document.querySelector(`#${groupId}`);
         if (!tile) {
@@ -185,9 +383,18 @@ export default class Board {
             statusDots.classList.add("dots");
             tile.append(statusDots);
 
+            const ack = document.createElement("span");
+            ack.classList.add("ack");
+            tile.append(ack);
+
 
             tile.addEventListener("click", e => {
-                this.displayGroupDetails(group);
+                if (!current && group.groups?.length > 0) {
+                    // Drill down
+                    location.hash = "#/" + this.currentGroups().concat(group).map(g => encodeURIComponent(g.name)).join("/");
+                } else {
+                    this.displayGroupDetails(group);
+                }
             });
         }
         tile.classList.toggle("inactive", group.inactive);
@@ -195,7 +402,7 @@ export default class Board {
     }
 
     async displayGroupDetails(group) {
-        const sortedEndpoints = group.endpoints.sort((e1, e2) => {
+        const sortedEndpoints = (group.endpoints ?? []).sort((e1, e2) => {
             if (e1.inactive === e2.inactive) {
                 return 0;
             } else if (e1.inactive) {
@@ -207,7 +414,7 @@ export default class Board {
         
         const rows = sortedEndpoints.map(endpoint => {
             const url = (endpoint.url.startsWith("http://") || endpoint.url.startsWith("https://")) ? endpoint.url : "";
-            const e = this.data[endpoint.url];
+            const e = this.data[endpoint.id];
             const status = e?.status ?? "grey"; 
             const code = (e?.code ?? 999) == 999 ? "-" : e?.code;
             return {
@@ -223,6 +430,33 @@ export default class Board {
                             target: "_blank",
                             rel: "noopener noreferrer"
                         }
+                    }, {
+                        type: "div",
+                        classes: ["pending", `pending_${e?.pending_status}`],
+                        textContent: e?.pending_status ? this.pendingText(endpoint, e) : ""
+                    }, {
+                        type: "div",
+                        classes: ["flapping"],
+                        textContent: e?.flapping ? "flapping, notifications are paused" : ""
+                    }, {
+                        type: "div",
+                        classes: ["dependencies"],
+                        textContent: this.dependencyText(group, endpoint, e)
+                    }, {
+                        type: "div",
+                        classes: ["maintenance"],
+                        textContent: e?.maintenance ? this.maintenanceText(e.maintenance) : ""
+                    }, {
+                        type: "div",
+                        classes: ["ack"],
+                        textContent: this.ackFor(group, endpoint) ? this.ackText(this.ackFor(group, endpoint)) : ""
+                    }, {
+                        type: "ul",
+                        classes: ["failedAssertions"],
+                        children: (e?.failed_assertions ?? []).map(failure => ({
+                            type: "li",
+                            textContent: failure
+                        }))
                     }],
                 }, {
                     type: "td",
@@ -236,17 +470,19 @@ export default class Board {
                         type: "button",
                         textContent: "?",
                         style: {
-                            display: (!e?.body || endpoint.inactive) ? "none" : undefined
+                            display: ((!e?.body && !e?.has_body) || endpoint.inactive) ? "none" : undefined
                         },
                         events: {
-                            click: event => {
+                            click: async event => {
                                 event.preventDefault();
 
-                                let body = e.body ? atob(e.body) : "";
+                                const details = e.body ? e : await this.request("read?id=" + encodeURIComponent(endpoint.id));
+                                const contentType = details.content_type ?? "";
+                                let body = details.body ? atob(details.body) : "";
                                 const shortened = body.length > 28;
 
                                 let content;
-                                if (e.content_type.startsWith("application/json")) {
+                                if (contentType.startsWith("application/json")) {
                                     try {
                                         body = JSON.stringify(JSON.parse(body), null, 2);
                                     } catch (ex) {
@@ -256,7 +492,7 @@ export default class Board {
 
 
 
-                                if (e.content_type.startsWith("text/html")) {
+                                if (contentType.startsWith("text/html")) {
                                     content = d({
                                         type: "div",
                                         textContent: body,
@@ -294,7 +530,11 @@ export default class Board {
         });
 
 
+        const groupAck = this.ackFor(group);
         const content = [d({
+            classes: ["ack"],
+            textContent: groupAck ? this.ackText(groupAck) : ""
+        }), d({
             children: [{
                 type: "table",
                 children: [{
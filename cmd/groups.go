@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// AllGroups returns all groups including their subgroups (to any depth). Parents come before
+// their subgroups.
+func (c *Configuration) AllGroups() []*Group {
+	return c.allGroups
+}
+
+// prepareGroups collects all groups, links the subgroups to their parents and lets them inherit
+// the settings of their parents. Group names must be unique across all levels.
+func (c *Configuration) prepareGroups() error {
+	c.allGroups = make([]*Group, 0, len(c.Groups))
+	names := make(map[string]bool)
+
+	var add func(parent *Group, groups []*Group) error
+	add = func(parent *Group, groups []*Group) error {
+		for _, group := range groups {
+			if names[group.Name] {
+				return fmt.Errorf("group %s: name is not unique", group.Name)
+			}
+			names[group.Name] = true
+			if group.Weight < 0 {
+				return fmt.Errorf("group %s: weight must not be negative", group.Name)
+			}
+
+			if parent != nil {
+				err := group.inherit(parent)
+				if err != nil {
+					return fmt.Errorf("group %s: %s", group.Name, err.Error())
+				}
+			}
+			group.parent = parent
+			c.allGroups = append(c.allGroups, group)
+
+			err := add(group, group.Groups)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return add(nil, c.Groups)
+}
+
+// inherit takes the settings of the parent group that are not set in the subgroup. The URL of the
+// subgroup is resolved against the one of the parent.
+func (g *Group) inherit(parent *Group) error {
+	if parent.URL != "" {
+		base, err := url.Parse(parent.URL)
+		if err != nil {
+			return fmt.Errorf("invalid base URL of group %s: %s", parent.Name, err.Error())
+		}
+		uri, err := base.Parse(g.URL)
+		if err != nil {
+			return fmt.Errorf("invalid URL %s: %s", g.URL, err.Error())
+		}
+		g.URL = uri.String()
+	}
+
+	g.Inactive = g.Inactive || parent.Inactive
+	if g.Interval == 0 {
+		g.Interval = parent.Interval
+	}
+	if g.Timeout == 0 {
+		g.Timeout = parent.Timeout
+	}
+	if g.Retries == 0 {
+		g.Retries = parent.Retries
+	}
+	if g.RetryDelay == 0 {
+		g.RetryDelay = parent.RetryDelay
+	}
+	if g.FailureThreshold == 0 {
+		g.FailureThreshold = parent.FailureThreshold
+	}
+	if g.SuccessThreshold == 0 {
+		g.SuccessThreshold = parent.SuccessThreshold
+	}
+	if g.Flapping.Threshold == 0 {
+		g.Flapping = parent.Flapping
+	}
+	dependsOn := make(StringList, 0, len(parent.DependsOn)+len(g.DependsOn))
+	for _, reference := range parent.DependsOn {
+		if !strings.Contains(reference, "/") {
+			// Endpoints of the parent group
+			reference = parent.Name + "/" + reference
+		}
+		dependsOn = append(dependsOn, reference)
+	}
+	g.DependsOn = append(dependsOn, g.DependsOn...)
+	g.RequestOptions.inherit(parent.RequestOptions)
+	return nil
+}
+
+// path returns the names of the group and its parents, starting with the top level group
+func (g *Group) path() []string {
+	if g.parent == nil {
+		return []string{g.Name}
+	}
+	return append(g.parent.path(), g.Name)
+}
+
+// weight returns the weight of the subgroup for the status of its parent
+func (g *Group) weight() float64 {
+	if g.Weight == 0 {
+		return 1
+	}
+	return g.Weight
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func readTestConfiguration(t *testing.T, content string) (*Configuration, error) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	err := os.WriteFile(path, []byte(content), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ReadConfiguration(path)
+}
+
+// A group that only contains subgroups and no endpoints of its own
+const subgroupOnlyConfiguration = `
+title: Test
+refreshInterval: 10
+authorization:
+  type: none
+groups:
+  - name: Production
+    url: http://example.com/
+    groups:
+      - name: Europe
+        endpoints:
+          - name: Shop
+            url: /shop
+          - name: API
+            url: /api
+            critical: true
+      - name: America
+        weight: 2
+        groups:
+          - name: East
+            endpoints:
+              - name: Shop
+                url: http://east.example.com/shop
+`
+
+func TestSubgroupOnlyConfiguration(t *testing.T) {
+	config, err := readTestConfiguration(t, subgroupOnlyConfiguration)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names := make([]string, 0)
+	for _, group := range config.AllGroups() {
+		names = append(names, strings.Join(group.path(), "/"))
+	}
+	if strings.Join(names, ",") != "Production,Production/Europe,Production/America,Production/America/East" {
+		t.Errorf("unexpected groups %v", names)
+	}
+
+	production := config.Groups[0]
+	if len(production.Endpoints) != 0 {
+		t.Errorf("expected no endpoints, got %d", len(production.Endpoints))
+	}
+	europe := production.Groups[0]
+	if europe.Endpoints[0].ID != "Europe/Shop" {
+		t.Errorf("unexpected ID %s", europe.Endpoints[0].ID)
+	}
+	if uri, err := EndpointURL(europe, europe.Endpoints[0]); err != nil || uri.String() != "http://example.com/shop" {
+		t.Errorf("unexpected URL %v (%v)", uri, err)
+	}
+
+	state, _ := NewState("")
+	s := &Server{
+		results:     make(map[string]*Result),
+		groupStatus: make(map[string]Status),
+		state:       state,
+	}
+	s.configuration.Store(config)
+	compute := func() Status {
+		groups := config.AllGroups()
+		for i := len(groups) - 1; i >= 0; i-- {
+			s.groupStatus[groups[i].Name] = s.computeGroupStatus(groups[i])
+		}
+		return s.groupStatus["Production"]
+	}
+
+	if status := compute(); status != STATUS_INACTIVE {
+		t.Errorf("expected %s without results, got %s", STATUS_INACTIVE, status)
+	}
+
+	s.results["Europe/Shop"] = &Result{Status: STATUS_GREEN}
+	s.results["Europe/API"] = &Result{Status: STATUS_GREEN}
+	s.results["East/Shop"] = &Result{Status: STATUS_GREEN}
+	if status := compute(); status != STATUS_GREEN {
+		t.Errorf("expected %s, got %s", STATUS_GREEN, status)
+	}
+
+	// America weighs twice as much as Europe
+	s.results["East/Shop"] = &Result{Status: STATUS_RED}
+	if status := compute(); status != STATUS_RED {
+		t.Errorf("expected %s, got %s", STATUS_RED, status)
+	}
+
+	// A critical endpoint makes its group red, which is not critical for the parent
+	s.results["East/Shop"] = &Result{Status: STATUS_GREEN}
+	s.results["Europe/API"] = &Result{Status: STATUS_RED}
+	if status := compute(); status != STATUS_YELLOW {
+		t.Errorf("expected %s, got %s", STATUS_YELLOW, status)
+	}
+	if s.groupStatus["Europe"] != STATUS_RED {
+		t.Errorf("expected %s for Europe, got %s", STATUS_RED, s.groupStatus["Europe"])
+	}
+}
+
+func TestGroupConfigurationErrors(t *testing.T) {
+	tests := []struct {
+		groups string
+		err    string
+	}{
+		{`
+  - name: A
+    groups:
+      - name: A
+`, "not unique"},
+		{`
+  - name: A
+    groups:
+      - name: B
+        weight: -1
+`, "weight must not be negative"},
+		{`
+  - name: A
+    weight: -2
+`, "weight must not be negative"},
+	}
+
+	for _, test := range tests {
+		_, err := readTestConfiguration(t, "refreshInterval: 10\nauthorization:\n  type: none\ngroups:"+test.groups)
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("expected error %q, got %v", test.err, err)
+		}
+	}
+}
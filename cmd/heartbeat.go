@@ -0,0 +1,294 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	heartbeatDefaultGrace     = 60
+	heartbeatMinTokenLength   = 16
+	heartbeatMaxMessageLength = 1024
+)
+
+// HeartbeatOptions configure "heartbeat://" endpoints, which are not requested but expect to be
+// called (pushed) by jobs via /api/push/<token>. The next push is expected interval seconds after
+// the last one or at the next time matching the cron schedule.
+type HeartbeatOptions struct {
+	Interval float64 `yaml:"interval,omitempty" json:"interval,omitempty"`
+	Schedule string  `yaml:"schedule,omitempty" json:"schedule,omitempty"`
+	Grace    float64 `yaml:"grace,omitempty" json:"grace,omitempty"`
+	Token    Secret  `yaml:"token,omitempty" json:"-"`
+	cron     *CronSchedule
+	token    string
+}
+
+// HeartbeatState is the token and the last push of a heartbeat endpoint
+type HeartbeatState struct {
+	Token    string    `json:"token"`
+	Created  time.Time `json:"created"`
+	LastPush time.Time `json:"last_push,omitempty"`
+	Status   Status    `json:"status,omitempty"`
+	Message  string    `json:"message,omitempty"`
+}
+
+// HeartbeatProber evaluates heartbeat endpoints with the pushes stored in the state of the server
+type HeartbeatProber struct {
+	state atomic.Pointer[State]
+}
+
+var heartbeatProber = &HeartbeatProber{}
+
+func init() {
+	RegisterProber("heartbeat", heartbeatProber)
+}
+
+// SetState sets the state that contains the pushes
+func (p *HeartbeatProber) SetState(state *State) {
+	p.state.Store(state)
+}
+
+// PushResponse confirms a received push
+type PushResponse struct {
+	Group    string    `json:"group"`
+	Endpoint string    `json:"endpoint"`
+	Status   Status    `json:"status"`
+	Received time.Time `json:"received"`
+}
+
+// prepare validates the options, parses the schedule and reads the token
+func (h *HeartbeatOptions) prepare(configDir string) error {
+	if h.Interval <= 0 && h.Schedule == "" {
+		return fmt.Errorf("interval or schedule must be set")
+	}
+	if h.Schedule != "" {
+		cron, err := ParseCron(h.Schedule)
+		if err != nil {
+			return err
+		}
+		h.cron = cron
+	}
+
+	if h.Grace < 0 {
+		return fmt.Errorf("grace must not be negative")
+	} else if h.Grace == 0 {
+		h.Grace = heartbeatDefaultGrace
+	}
+
+	if h.Token.isSet() {
+		token, err := h.Token.read(configDir)
+		if err != nil {
+			return fmt.Errorf("token: %s", err.Error())
+		}
+		if len(token) < heartbeatMinTokenLength {
+			return fmt.Errorf("token must have at least %d characters", heartbeatMinTokenLength)
+		}
+		h.token = token
+	}
+	return nil
+}
+
+// next returns when the next push is expected after the given time
+func (h *HeartbeatOptions) next(after time.Time) time.Time {
+	if h.cron != nil {
+		return h.cron.Next(after)
+	}
+	return after.Add(time.Duration(h.Interval * float64(time.Second)))
+}
+
+func newHeartbeatToken() string {
+	token := make([]byte, 24)
+	_, _ = rand.Read(token)
+	return hex.EncodeToString(token)
+}
+
+// tokenFor returns the configured token of the heartbeat endpoint or the generated one
+func (s *Server) tokenFor(group *Group, endpoint *Endpoint) string {
+	if endpoint.Heartbeat.token != "" {
+		return endpoint.Heartbeat.token
+	}
+	return s.state.Heartbeat(endpoint.ID).Token
+}
+
+// heartbeatByToken returns the heartbeat endpoint with the given token or nil
+func (s *Server) heartbeatByToken(token string) (*Group, *Endpoint) {
+	for _, group := range s.config().AllGroups() {
+		for _, endpoint := range group.Endpoints {
+			uri, err := EndpointURL(group, endpoint)
+			if err != nil || uri.Scheme != "heartbeat" {
+				continue
+			}
+			if subtle.ConstantTimeCompare([]byte(s.tokenFor(group, endpoint)), []byte(token)) == 1 {
+				return group, endpoint
+			}
+		}
+	}
+	return nil, nil
+}
+
+// HeartbeatResponse lists the push URLs of all heartbeat endpoints with generated tokens
+type HeartbeatResponse struct {
+	Heartbeats []HeartbeatURL `json:"heartbeats"`
+}
+
+type HeartbeatURL struct {
+	Group    string `json:"group"`
+	Endpoint string `json:"endpoint"`
+	PushURL  string `json:"push_url"`
+}
+
+// heartbeatURLs returns the push URLs of all heartbeat endpoints without configured token
+func (s *Server) heartbeatURLs() []HeartbeatURL {
+	urls := make([]HeartbeatURL, 0)
+	for _, group := range s.config().AllGroups() {
+		for _, endpoint := range group.Endpoints {
+			uri, err := EndpointURL(group, endpoint)
+			if err != nil || uri.Scheme != "heartbeat" || endpoint.Heartbeat.token != "" {
+				continue
+			}
+			urls = append(urls, HeartbeatURL{
+				Group:    group.Name,
+				Endpoint: endpoint.Name,
+				PushURL:  "/api/push/" + s.tokenFor(group, endpoint),
+			})
+		}
+	}
+	return urls
+}
+
+// logHeartbeatURLs writes the generated push URLs to the log, so they can be set up without access
+// to the API
+func (s *Server) logHeartbeatURLs() {
+	for _, heartbeat := range s.heartbeatURLs() {
+		out("Push URL of %s/%s: %s\n", heartbeat.Group, heartbeat.Endpoint, heartbeat.PushURL)
+	}
+}
+
+// handleHeartbeat lists the push URLs. Since they allow to report the status of endpoints, only
+// users identified by the authorization can read them.
+func (s *Server) handleHeartbeat(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.respond(w, r, Error{
+			Code:    405,
+			Message: "Method not allowed",
+		})
+		return
+	}
+	if s.requestUser(r) == "" {
+		s.respond(w, r, Error{
+			Code:    403,
+			Message: "Push URLs can only be read by authenticated users",
+		})
+		return
+	}
+	s.respond(w, r, HeartbeatResponse{Heartbeats: s.heartbeatURLs()})
+}
+
+// handlePushRequest records a push of a heartbeat endpoint. It does not require authorization,
+// the token identifies the endpoint. The optional parameters status (green, yellow or red) and
+// message can be sent as query or form parameters.
+func (s *Server) handlePushRequest(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	group, endpoint := s.heartbeatByToken(strings.TrimPrefix(r.URL.Path, "/api/push/"))
+	if endpoint == nil {
+		w.WriteHeader(http.StatusNotFound)
+		s.respond(w, r, Error{
+			Code:    404,
+			Message: "Unknown token",
+		})
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, 64*1024)
+	status := Status(r.FormValue("status"))
+	switch status {
+	case "":
+		status = STATUS_GREEN
+	case STATUS_GREEN, STATUS_YELLOW, STATUS_RED:
+		// Valid
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+		s.respond(w, r, Error{
+			Code:    400,
+			Message: fmt.Sprintf("status must be \"%s\", \"%s\" or \"%s\"", STATUS_GREEN, STATUS_YELLOW, STATUS_RED),
+		})
+		return
+	}
+
+	message := r.FormValue("message")
+	if len(message) > heartbeatMaxMessageLength {
+		message = message[:heartbeatMaxMessageLength]
+	}
+
+	now := time.Now()
+	err := s.state.RecordPush(endpoint.ID, status, message, now)
+	if err != nil {
+		outError("Cannot save push of %s/%s: %s\n", group.Name, endpoint.Name, err.Error())
+	}
+	outDebug("Push received for %s/%s: %s %s\n", group.Name, endpoint.Name, status, message)
+
+	s.recheck(group, endpoint.Name)
+	s.respond(w, r, PushResponse{
+		Group:    group.Name,
+		Endpoint: endpoint.Name,
+		Status:   status,
+		Received: now,
+	})
+}
+
+// Probe evaluates the last push of the endpoint. Until the first push is received, the endpoint is
+// grey. It is red if the next push is more than the grace period late, otherwise it has the status
+// sent with the last push.
+func (p *HeartbeatProber) Probe(ctx context.Context, group *Group, endpoint *Endpoint) *Result {
+	state := p.state.Load()
+	if state == nil {
+		return &Result{Status: STATUS_INACTIVE}
+	}
+	options := endpoint.Heartbeat
+	heartbeat := state.Heartbeat(endpoint.ID)
+
+	since := heartbeat.LastPush
+	if since.IsZero() {
+		since = heartbeat.Created
+	}
+	expected := options.next(since)
+	deadline := expected.Add(time.Duration(options.Grace * float64(time.Second)))
+	late := !expected.IsZero() && time.Now().After(deadline)
+
+	body := strings.Builder{}
+	result := &Result{
+		Status:      heartbeat.Status,
+		ContentType: "text/plain",
+	}
+	if heartbeat.LastPush.IsZero() {
+		result.Status = STATUS_INACTIVE
+		body.WriteString("No push received yet\n")
+	} else {
+		fmt.Fprintf(&body, "Last push: %s (%s)\n", heartbeat.LastPush.Format(time.RFC3339), heartbeat.Status)
+		if heartbeat.Message != "" {
+			fmt.Fprintf(&body, "Message: %s\n", heartbeat.Message)
+			if heartbeat.Status != STATUS_GREEN {
+				result.FailedAssertions = append(result.FailedAssertions, heartbeat.Message)
+			}
+		}
+	}
+	if !expected.IsZero() {
+		fmt.Fprintf(&body, "Next push expected: %s (grace period until %s)\n", expected.Format(time.RFC3339), deadline.Format(time.RFC3339))
+	}
+
+	if late {
+		result.Status = STATUS_RED
+		result.FailedAssertions = append(result.FailedAssertions, fmt.Sprintf("no push received since %s", since.Format(time.RFC3339)))
+	}
+
+	result.Body = []byte(body.String())
+	return result
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestHeartbeatProbe(t *testing.T) {
+	state, err := NewState("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	prober := &HeartbeatProber{}
+	prober.SetState(state)
+
+	now := time.Now()
+	tests := []struct {
+		name     string
+		options  HeartbeatOptions
+		created  time.Duration // Age of the token
+		push     time.Duration // Age of the last push, 0 for none
+		status   Status
+		message  string
+		expected Status
+	}{
+		{"first", HeartbeatOptions{Interval: 60, Grace: 30}, 0, 0, "", "", STATUS_INACTIVE},
+		{"on time", HeartbeatOptions{Interval: 60, Grace: 30}, time.Hour, 10 * time.Second, STATUS_GREEN, "", STATUS_GREEN},
+		{"within grace", HeartbeatOptions{Interval: 60, Grace: 30}, time.Hour, 80 * time.Second, STATUS_GREEN, "", STATUS_GREEN},
+		{"late", HeartbeatOptions{Interval: 60, Grace: 30}, time.Hour, 100 * time.Second, STATUS_GREEN, "", STATUS_RED},
+		{"pushed yellow", HeartbeatOptions{Interval: 60, Grace: 30}, time.Hour, 10 * time.Second, STATUS_YELLOW, "slow", STATUS_YELLOW},
+		{"pushed red", HeartbeatOptions{Interval: 60, Grace: 30}, time.Hour, 10 * time.Second, STATUS_RED, "failed", STATUS_RED},
+		{"late yellow", HeartbeatOptions{Interval: 60, Grace: 30}, time.Hour, 100 * time.Second, STATUS_YELLOW, "slow", STATUS_RED},
+		// Without push since the token was created
+		{"never", HeartbeatOptions{Interval: 60, Grace: 30}, 100 * time.Second, 0, "", "", STATUS_RED},
+		{"default grace", HeartbeatOptions{Interval: 60}, time.Hour, 110 * time.Second, STATUS_GREEN, "", STATUS_GREEN},
+		// The next push is expected at most a minute after the last one
+		{"schedule", HeartbeatOptions{Schedule: "* * * * *", Grace: 30}, time.Hour, 10 * time.Second, STATUS_GREEN, "", STATUS_GREEN},
+		{"schedule late", HeartbeatOptions{Schedule: "* * * * *", Grace: 30}, time.Hour, 3 * time.Minute, STATUS_GREEN, "", STATUS_RED},
+	}
+
+	for _, test := range tests {
+		endpoint := &Endpoint{Name: test.name, ID: "Jobs/" + test.name, Heartbeat: test.options}
+		if err := endpoint.Heartbeat.prepare(""); err != nil {
+			t.Fatal(err)
+		}
+		state.Heartbeats[endpoint.ID] = &HeartbeatState{Created: now.Add(-test.created)}
+		if test.push > 0 {
+			if err := state.RecordPush(endpoint.ID, test.status, test.message, now.Add(-test.push)); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		result := prober.Probe(context.Background(), &Group{Name: "Jobs"}, endpoint)
+		if result.Status != test.expected {
+			t.Errorf("%s: expected %s, got %s (%s)", test.name, test.expected, result.Status, result.Body)
+		}
+		if test.message != "" && test.status != STATUS_GREEN && (len(result.FailedAssertions) == 0 || result.FailedAssertions[0] != test.message) {
+			t.Errorf("%s: expected the message as failed assertion, got %v", test.name, result.FailedAssertions)
+		}
+	}
+}
@@ -0,0 +1,352 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	historyDefaultRetentionDays       = 35
+	historyDefaultDownsampleAfterDays = 2
+	historyDefaultDownsampleMinutes   = 60
+)
+
+type HistoryConfiguration struct {
+	RetentionDays       float64 `yaml:"retentionDays"`
+	DownsampleAfterDays float64 `yaml:"downsampleAfterDays"`
+	DownsampleMinutes   float64 `yaml:"downsampleMinutes"`
+}
+
+// HistoryEntry is one check result or, after downsampling, the combination of several results
+type HistoryEntry struct {
+	Time     time.Time `json:"t"`
+	Status   Status    `json:"s"`
+	Code     int       `json:"c,omitempty"`
+	Duration float64   `json:"d,omitempty"`
+	Checks   int       `json:"n,omitempty"` // Number of combined checks, 0 for a single check
+	Up       int       `json:"u,omitempty"` // Number of combined checks that were up
+}
+
+// counts returns how many checks the entry represents and how many of them were up.
+// Inactive entries do not count.
+func (e *HistoryEntry) counts() (up int, total int) {
+	if e.Checks > 0 {
+		return e.Up, e.Checks
+	}
+	switch e.Status {
+	case STATUS_GREEN, STATUS_YELLOW:
+		return 1, 1
+	case STATUS_RED, STATUS_BLOCKED:
+		return 0, 1
+	}
+	return 0, 0
+}
+
+// historyRecord is the line format of the history file. Older files identify the endpoint by the
+// names of the group and the endpoint instead of its ID.
+type historyRecord struct {
+	ID       string `json:"id,omitempty"`
+	Group    string `json:"g,omitempty"`
+	Endpoint string `json:"e,omitempty"`
+	HistoryEntry
+}
+
+// History stores the results of all checks in memory and appends them to a file, so that they
+// survive restarts. Compact applies retention and downsampling and rewrites the file.
+type History struct {
+	mutex   sync.Mutex
+	config  HistoryConfiguration
+	file    *os.File
+	entries map[string][]HistoryEntry // By endpoint ID
+}
+
+func NewHistory(historyFile string, config HistoryConfiguration) (*History, error) {
+	h := &History{
+		config:  config,
+		entries: make(map[string][]HistoryEntry),
+	}
+
+	file, err := os.OpenFile(historyFile, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	h.file = file
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		record := historyRecord{}
+		err = json.Unmarshal(scanner.Bytes(), &record)
+		if err != nil {
+			outError("Ignoring invalid line %d in history file %s: %s\n", line, historyFile, err.Error())
+			continue
+		}
+		key := record.ID
+		if key == "" {
+			// Migrated to the ID with MigrateKeys
+			key = record.Group + "/" + record.Endpoint
+		}
+		h.entries[key] = append(h.entries[key], record.HistoryEntry)
+	}
+	if scanner.Err() != nil {
+		file.Close()
+		return nil, scanner.Err()
+	}
+
+	for _, entries := range h.entries {
+		sortHistoryEntries(entries)
+	}
+
+	return h, nil
+}
+
+func sortHistoryEntries(entries []HistoryEntry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Time.Before(entries[j].Time)
+	})
+}
+
+// MigrateKeys moves the entries that are stored by group and endpoint name (as in older history
+// files or before an ID was set) to the ID of the endpoint and rewrites the file if necessary
+func (h *History) MigrateKeys(config *Configuration) error {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	changed := migrateKeys(config, h.entries, func(existing []HistoryEntry, moved []HistoryEntry) []HistoryEntry {
+		merged := append(existing, moved...)
+		sortHistoryEntries(merged)
+		return merged
+	})
+	if !changed {
+		return nil
+	}
+	return h.rewrite()
+}
+
+func (h *History) SetConfiguration(config HistoryConfiguration) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.config = config
+}
+
+// Record stores the result for the endpoint with the given ID
+func (h *History) Record(id string, result *Result) {
+	record := historyRecord{
+		ID: id,
+		HistoryEntry: HistoryEntry{
+			Time:     result.Updated,
+			Status:   result.Status,
+			Code:     result.Code,
+			Duration: result.RequestDuration,
+		},
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		outError("Cannot save result to history: %s\n", err.Error())
+		return
+	}
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.entries[id] = append(h.entries[id], record.HistoryEntry)
+
+	_, err = h.file.Write(append(data, '\n'))
+	if err != nil {
+		outError("Cannot save result to history: %s\n", err.Error())
+	}
+}
+
+// Entries returns all entries for the endpoint with the given ID in the given time range
+func (h *History) Entries(id string, from time.Time, to time.Time) []HistoryEntry {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	entries := h.entries[id]
+	start := sort.Search(len(entries), func(i int) bool {
+		return !entries[i].Time.Before(from)
+	})
+
+	selected := make([]HistoryEntry, 0)
+	for _, entry := range entries[start:] {
+		if entry.Time.After(to) {
+			break
+		}
+		selected = append(selected, entry)
+	}
+	return selected
+}
+
+// Uptime returns the percentage of checks since the given time that were up or nil if there were no checks
+func (h *History) Uptime(id string, since time.Time) *float64 {
+	up, total := 0, 0
+	for _, entry := range h.Entries(id, since, time.Now()) {
+		entryUp, entryTotal := entry.counts()
+		up += entryUp
+		total += entryTotal
+	}
+
+	if total == 0 {
+		return nil
+	}
+	uptime := float64(up) / float64(total) * 100
+	return &uptime
+}
+
+// Compact removes entries older than the retention period, combines old entries into one entry
+// per downsampling interval and rewrites the history file
+func (h *History) Compact() error {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	now := time.Now()
+	retentionLimit := now.Add(-time.Duration(h.config.RetentionDays * 24 * float64(time.Hour)))
+	downsampleLimit := now.Add(-time.Duration(h.config.DownsampleAfterDays * 24 * float64(time.Hour)))
+	interval := time.Duration(h.config.DownsampleMinutes * float64(time.Minute))
+
+	for key, entries := range h.entries {
+		compacted := make([]HistoryEntry, 0, len(entries))
+		var bucket *HistoryEntry
+		var bucketDuration float64
+		for _, entry := range entries {
+			if entry.Time.Before(retentionLimit) {
+				continue
+			}
+			if !entry.Time.Before(downsampleLimit) {
+				if bucket != nil {
+					compacted = append(compacted, *bucket)
+					bucket = nil
+				}
+				compacted = append(compacted, entry)
+				continue
+			}
+
+			bucketTime := entry.Time.Truncate(interval)
+			if bucket != nil && !bucket.Time.Equal(bucketTime) {
+				compacted = append(compacted, *bucket)
+				bucket = nil
+			}
+
+			up, checks := entry.counts()
+			if bucket == nil {
+				bucket = &HistoryEntry{
+					Time:   bucketTime,
+					Status: entry.Status,
+				}
+				bucketDuration = 0
+			}
+			bucket.Code = entry.Code
+			bucket.Up += up
+			bucket.Checks += checks
+			bucket.Status = bucketStatus(bucket.Status, entry.Status)
+			if checks > 0 {
+				bucketDuration += entry.Duration * float64(checks)
+				bucket.Duration = bucketDuration / float64(bucket.Checks)
+			}
+		}
+		if bucket != nil {
+			compacted = append(compacted, *bucket)
+		}
+
+		if len(compacted) == 0 {
+			delete(h.entries, key)
+		} else {
+			h.entries[key] = compacted
+		}
+	}
+
+	return h.rewrite()
+}
+
+// rewrite replaces the history file with the current entries. Must be called with the mutex locked.
+func (h *History) rewrite() error {
+	name := h.file.Name()
+	tmpFile, err := os.CreateTemp(filepath.Dir(name), filepath.Base(name)+".*")
+	if err != nil {
+		return err
+	}
+
+	writer := bufio.NewWriter(tmpFile)
+	encoder := json.NewEncoder(writer)
+write:
+	for key, entries := range h.entries {
+		for _, entry := range entries {
+			err = encoder.Encode(historyRecord{ID: key, HistoryEntry: entry})
+			if err != nil {
+				break write
+			}
+		}
+	}
+	if err == nil {
+		err = writer.Flush()
+	}
+	if err == nil {
+		err = tmpFile.Close()
+	}
+	if err == nil {
+		err = os.Rename(tmpFile.Name(), name)
+	}
+	if err != nil {
+		tmpFile.Close()
+		os.Remove(tmpFile.Name())
+		return fmt.Errorf("cannot rewrite history file %s: %s", name, err.Error())
+	}
+
+	file, err := os.OpenFile(name, os.O_RDWR|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	h.file.Close()
+	h.file = file
+	return nil
+}
+
+func (h *History) Close() error {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	return h.file.Close()
+}
+
+// worseStatus returns the more severe of both status
+func worseStatus(a Status, b Status) Status {
+	if statusSeverity(b) > statusSeverity(a) {
+		return b
+	}
+	return a
+}
+
+// bucketStatus returns the status of downsampled checks: the more severe status, but maintenance
+// instead of green or inactive, so that maintenance windows stay visible in the history
+func bucketStatus(a Status, b Status) Status {
+	if a == STATUS_MAINTENANCE {
+		a, b = b, a
+	}
+	if b == STATUS_MAINTENANCE && statusSeverity(a) <= statusSeverity(STATUS_GREEN) {
+		return STATUS_MAINTENANCE
+	}
+	return worseStatus(a, b)
+}
+
+// statusSeverity orders the status from green (1) to red (3). Blocked endpoints are red with a
+// failing dependency, so they are as severe as red (and count as down like red). Other status
+// (inactive, maintenance and unknown ones) have no severity.
+func statusSeverity(status Status) int {
+	switch status {
+	case STATUS_GREEN:
+		return 1
+	case STATUS_YELLOW:
+		return 2
+	case STATUS_RED, STATUS_BLOCKED:
+		return 3
+	}
+	return 0
+}
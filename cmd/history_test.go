@@ -0,0 +1,106 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWorseStatus(t *testing.T) {
+	tests := []struct {
+		a        Status
+		b        Status
+		expected Status
+	}{
+		{STATUS_GREEN, STATUS_YELLOW, STATUS_YELLOW},
+		{STATUS_RED, STATUS_YELLOW, STATUS_RED},
+		{STATUS_INACTIVE, STATUS_GREEN, STATUS_GREEN},
+
+		// Blocked endpoints are down like red ones
+		{STATUS_GREEN, STATUS_BLOCKED, STATUS_BLOCKED},
+		{STATUS_BLOCKED, STATUS_YELLOW, STATUS_BLOCKED},
+		{STATUS_RED, STATUS_BLOCKED, STATUS_RED},
+		{STATUS_BLOCKED, STATUS_RED, STATUS_BLOCKED},
+	}
+
+	for _, test := range tests {
+		if actual := worseStatus(test.a, test.b); actual != test.expected {
+			t.Errorf("%q, %q: expected %q, got %q", test.a, test.b, test.expected, actual)
+		}
+	}
+
+	// The severity is consistent with the uptime: down is always worse than up
+	for _, status := range []Status{STATUS_GREEN, STATUS_YELLOW, STATUS_RED, STATUS_BLOCKED} {
+		entry := HistoryEntry{Status: status}
+		up, total := entry.counts()
+		if total != 1 {
+			t.Errorf("%q: expected to count as check", status)
+		}
+		if (up == 0) != (statusSeverity(status) == statusSeverity(STATUS_RED)) {
+			t.Errorf("%q: severity %d does not match up %d", status, statusSeverity(status), up)
+		}
+	}
+}
+
+func TestCompact(t *testing.T) {
+	history, err := NewHistory(filepath.Join(t.TempDir(), "history.jsonl"), HistoryConfiguration{
+		RetentionDays:       10,
+		DownsampleAfterDays: 1,
+		DownsampleMinutes:   60,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer history.Close()
+
+	start := time.Now().Add(-48 * time.Hour).Truncate(time.Hour)
+	tests := []struct {
+		statuses string
+		expected Status
+		up       int
+		checks   int
+	}{
+		{"GGGG", STATUS_GREEN, 4, 4},
+		{"GYGG", STATUS_YELLOW, 4, 4},
+		{"GBBG", STATUS_BLOCKED, 2, 4},
+		{"GRBG", STATUS_RED, 2, 4},
+		{"GIIG", STATUS_GREEN, 2, 2},
+		// Maintenance stays visible unless checks failed
+		{"GMMG", STATUS_MAINTENANCE, 2, 2},
+		{"MMMM", STATUS_MAINTENANCE, 0, 0},
+		{"IMMI", STATUS_MAINTENANCE, 0, 0},
+		{"MYMM", STATUS_YELLOW, 1, 1},
+		{"MBMM", STATUS_BLOCKED, 0, 1},
+	}
+
+	for i, test := range tests {
+		id := test.statuses
+		for j := range test.statuses {
+			history.Record(id, &Result{
+				Status:  testStatusLetters[test.statuses[j]],
+				Updated: start.Add(time.Duration(i)*time.Hour + time.Duration(j)*time.Minute),
+			})
+		}
+	}
+	// Recent results are kept
+	history.Record("recent", &Result{Status: STATUS_BLOCKED, Updated: time.Now()})
+
+	if err := history.Compact(); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, test := range tests {
+		entries := history.Entries(test.statuses, start.Add(-time.Hour), time.Now())
+		if len(entries) != 1 {
+			t.Errorf("%s: expected one entry, got %v", test.statuses, entries)
+			continue
+		}
+		entry := entries[0]
+		if !entry.Time.Equal(start.Add(time.Duration(i)*time.Hour)) || entry.Status != test.expected || entry.Up != test.up || entry.Checks != test.checks {
+			t.Errorf("%s: expected %s with %d/%d up, got %+v", test.statuses, test.expected, test.up, test.checks, entry)
+		}
+	}
+	if entries := history.Entries("recent", start, time.Now()); len(entries) != 1 || entries[0].Checks != 0 {
+		t.Errorf("expected the recent entry unchanged, got %v", entries)
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+)
+
+// prepareID derives the ID of the endpoint from the names of its group and itself unless it is set
+// explicitly. IDs are used as keys of the results, so they must be unique.
+func (e *Endpoint) prepareID(group *Group, ids map[string]bool) error {
+	if e.ID == "" {
+		e.ID = group.Name + "/" + e.Name
+	}
+	if ids[e.ID] {
+		return fmt.Errorf("id %s is not unique", e.ID)
+	}
+	ids[e.ID] = true
+	return nil
+}
+
+// endpointByID returns the endpoint with the given ID and its group or nil
+func (s *Server) endpointByID(id string) (*Group, *Endpoint) {
+	for _, group := range s.config().AllGroups() {
+		for _, endpoint := range group.Endpoints {
+			if endpoint.ID == id {
+				return group, endpoint
+			}
+		}
+	}
+	return nil, nil
+}
+
+// migrateResultKeys moves results that are stored by URL (as in older cache files) to the ID of
+// their endpoint. URLs that are used by more than one endpoint cannot be assigned and are dropped
+// like results of unknown endpoints. Returns whether results have been changed.
+func migrateResultKeys(config *Configuration, results map[string]*Result) bool {
+	byURL := make(map[string][]*Endpoint)
+	known := make(map[string]bool)
+	for _, group := range config.AllGroups() {
+		for _, endpoint := range group.Endpoints {
+			known[endpoint.ID] = true
+			urls := []string{endpoint.URL}
+			if uri, err := EndpointURL(group, endpoint); err == nil && uri.String() != endpoint.URL {
+				urls = append(urls, uri.String())
+			}
+			for _, url := range urls {
+				byURL[url] = append(byURL[url], endpoint)
+			}
+		}
+	}
+
+	changed := false
+	for key, result := range results {
+		if known[key] {
+			continue
+		}
+
+		endpoints := byURL[key]
+		if len(endpoints) == 1 {
+			if _, ok := results[endpoints[0].ID]; !ok {
+				results[endpoints[0].ID] = result
+			}
+		}
+		delete(results, key)
+		changed = true
+	}
+	return changed
+}
+
+// endpointIDsByName maps "Group/Endpoint" (the key of older state and history files) to the IDs
+// of the endpoints
+func endpointIDsByName(config *Configuration) map[string]string {
+	ids := make(map[string]string)
+	for _, group := range config.AllGroups() {
+		for _, endpoint := range group.Endpoints {
+			ids[group.Name+"/"+endpoint.Name] = endpoint.ID
+		}
+	}
+	return ids
+}
+
+// migrateKeys moves the values of keys that are not an endpoint ID to the ID of the endpoint they
+// name (as "Group/Endpoint"). Values of keys that are already used are kept, the others are
+// merged with merge. Returns whether values have been moved.
+func migrateKeys[V any](config *Configuration, values map[string]V, merge func(existing V, moved V) V) bool {
+	names := endpointIDsByName(config)
+	known := make(map[string]bool, len(names))
+	for _, id := range names {
+		known[id] = true
+	}
+
+	changed := false
+	for key, value := range values {
+		id, ok := names[key]
+		if known[key] || !ok {
+			continue
+		}
+		if existing, ok := values[id]; ok {
+			value = merge(existing, value)
+		}
+		values[id] = value
+		delete(values, key)
+		changed = true
+	}
+	return changed
+}
+
+// migrateStateKeys moves the history, heartbeats and acknowledgements that are stored by group
+// and endpoint name to the IDs of the endpoints in the configuration
+func (s *Server) migrateStateKeys(config *Configuration) {
+	if s.history != nil {
+		err := s.history.MigrateKeys(config)
+		if err != nil {
+			outError("Cannot migrate history: %s\n", err.Error())
+		}
+	}
+	err := s.state.MigrateKeys(config)
+	if err != nil {
+		outError("Cannot migrate state: %s\n", err.Error())
+	}
+}
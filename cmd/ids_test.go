@@ -0,0 +1,109 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const idsConfiguration = `
+refreshInterval: 10
+authorization:
+  type: none
+groups:
+  - name: Shops
+    endpoints:
+      - name: Europe
+        id: shop-eu
+        url: http://eu.example.com/
+      - name: America
+        url: http://us.example.com/
+`
+
+func TestMigrateStateKeys(t *testing.T) {
+	config, err := readTestConfiguration(t, idsConfiguration)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// History and state as written before endpoints had IDs
+	dir := t.TempDir()
+	historyFile := filepath.Join(dir, "history.jsonl")
+	err = os.WriteFile(historyFile, []byte(strings.Join([]string{
+		`{"g":"Shops","e":"Europe","t":"2026-10-01T10:00:00Z","s":"green"}`,
+		`{"g":"Shops","e":"Europe","t":"2026-10-01T10:01:00Z","s":"red"}`,
+		`{"g":"Shops","e":"America","t":"2026-10-01T10:00:00Z","s":"green"}`,
+		`{"g":"Shops","e":"Removed","t":"2026-10-01T10:00:00Z","s":"green"}`,
+		`{"id":"shop-eu","t":"2026-10-01T10:02:00Z","s":"green"}`,
+	}, "\n")+"\n"), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stateFile := filepath.Join(dir, "state.json")
+	err = os.WriteFile(stateFile, []byte(`{
+		"heartbeats": {"Shops/Europe": {"token": "europe-token"}, "Shops/America": {"token": "america-token"}},
+		"acknowledgements": [
+			{"group": "Shops", "endpoint": "Europe", "author": "a"},
+			{"group": "Shops", "endpoint": "Removed", "author": "b"},
+			{"group": "Shops", "author": "c"}
+		]
+	}`), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	history, err := NewHistory(historyFile, config.History)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer history.Close()
+	state, err := NewState(stateFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &Server{history: history, state: state}
+	s.migrateStateKeys(config)
+
+	from, to := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 10, 2, 0, 0, 0, 0, time.UTC)
+	if entries := history.Entries("shop-eu", from, to); len(entries) != 3 || entries[1].Status != STATUS_RED {
+		t.Errorf("expected the merged entries of shop-eu, got %v", entries)
+	}
+	if entries := history.Entries("Shops/America", from, to); len(entries) != 1 {
+		t.Errorf("expected the entry of Shops/America, got %v", entries)
+	}
+
+	// The history file is rewritten with IDs
+	data, err := os.ReadFile(historyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), `"g":"Shops","e":"Europe"`) || !strings.Contains(string(data), `"id":"shop-eu"`) {
+		t.Errorf("history file not migrated:\n%s", data)
+	}
+
+	if token := state.Heartbeat("shop-eu").Token; token != "europe-token" {
+		t.Errorf("expected the token of Shops/Europe for shop-eu, got %s", token)
+	}
+	if token := state.Heartbeat("Shops/America").Token; token != "america-token" {
+		t.Errorf("expected the token of Shops/America, got %s", token)
+	}
+
+	acks := state.Acknowledgements()
+	if len(acks) != 2 {
+		t.Fatalf("expected 2 acknowledgements, got %d", len(acks))
+	}
+	if !state.Acknowledged("Shops", "shop-eu") || !state.Acknowledged("Shops", "") || state.Acknowledged("Shops", "Shops/America") {
+		t.Errorf("unexpected acknowledgements %+v %+v", *acks[0], *acks[1])
+	}
+
+	// The migration is persisted
+	state, err = NewState(stateFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := state.Heartbeats["Shops/Europe"]; ok || state.Acks[0].ID != "shop-eu" {
+		t.Errorf("state file not migrated")
+	}
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// JSONAssertion checks values in a JSON response. If the path matches several values, all of them
+// have to fulfill the assertion.
+type JSONAssertion struct {
+	Path     string `yaml:"path" json:"path"`
+	Operator string `yaml:"op,omitempty" json:"op,omitempty"`
+	Value    any    `yaml:"value,omitempty" json:"value,omitempty"`
+	Status   Status `yaml:"status,omitempty" json:"status,omitempty"`
+	path     *JSONPath
+	pattern  *regexp.Regexp
+}
+
+// prepare validates the assertion, sets defaults and parses the path
+func (a *JSONAssertion) prepare() error {
+	path, err := ParseJSONPath(a.Path)
+	if err != nil {
+		return err
+	}
+	a.path = path
+	a.Value = normalizeYAMLValue(a.Value)
+
+	if a.Operator == "" {
+		if a.Value == nil {
+			a.Operator = JSON_OP_EXISTS
+		} else {
+			a.Operator = JSON_OP_EQUALS
+		}
+	}
+
+	switch a.Operator {
+	case JSON_OP_EQUALS, JSON_OP_NOT_EQUALS, JSON_OP_CONTAINS, JSON_OP_EXISTS:
+		// Valid
+
+	case JSON_OP_GREATER_THAN, JSON_OP_LESS_THAN:
+		if _, ok := a.Value.(float64); !ok {
+			return fmt.Errorf("%s: value for %s must be a number", a.Path, a.Operator)
+		}
+
+	case JSON_OP_REGEX:
+		pattern, ok := a.Value.(string)
+		if !ok {
+			return fmt.Errorf("%s: value for %s must be a string", a.Path, a.Operator)
+		}
+		a.pattern, err = regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("%s: invalid regular expression: %s", a.Path, err.Error())
+		}
+
+	default:
+		return fmt.Errorf("%s: unknown operator %s", a.Path, a.Operator)
+	}
+
+	switch a.Status {
+	case "":
+		a.Status = STATUS_RED
+	case STATUS_RED, STATUS_YELLOW:
+		// Valid
+	default:
+		return fmt.Errorf("%s: status must be \"%s\" or \"%s\"", a.Path, STATUS_RED, STATUS_YELLOW)
+	}
+
+	return nil
+}
+
+// Check returns nil if the assertion is fulfilled by the document or an error describing why not
+func (a *JSONAssertion) Check(document any) error {
+	values := a.path.Evaluate(document)
+	if len(values) == 0 {
+		return fmt.Errorf("%s: not found", a.Path)
+	}
+	if a.Operator == JSON_OP_EXISTS {
+		return nil
+	}
+
+	for _, value := range values {
+		if !a.matches(value) {
+			valueJSON, _ := json.Marshal(value)
+			expectedJSON, _ := json.Marshal(a.Value)
+			return fmt.Errorf("%s: expected %s %s, got %s", a.Path, a.Operator, expectedJSON, valueJSON)
+		}
+	}
+	return nil
+}
+
+func (a *JSONAssertion) matches(value any) bool {
+	switch a.Operator {
+	case JSON_OP_EQUALS:
+		return reflect.DeepEqual(value, a.Value)
+
+	case JSON_OP_NOT_EQUALS:
+		return !reflect.DeepEqual(value, a.Value)
+
+	case JSON_OP_CONTAINS:
+		switch v := value.(type) {
+		case string:
+			expected, ok := a.Value.(string)
+			return ok && strings.Contains(v, expected)
+		case []any:
+			for _, element := range v {
+				if reflect.DeepEqual(element, a.Value) {
+					return true
+				}
+			}
+		case map[string]any:
+			key, ok := a.Value.(string)
+			if ok {
+				_, ok = v[key]
+			}
+			return ok
+		}
+		return false
+
+	case JSON_OP_GREATER_THAN, JSON_OP_LESS_THAN:
+		number, ok := value.(float64)
+		if !ok {
+			return false
+		}
+		if a.Operator == JSON_OP_GREATER_THAN {
+			return number > a.Value.(float64)
+		}
+		return number < a.Value.(float64)
+
+	case JSON_OP_REGEX:
+		text, ok := value.(string)
+		if !ok {
+			data, _ := json.Marshal(value)
+			text = string(data)
+		}
+		return a.pattern.MatchString(text)
+	}
+
+	return false
+}
+
+// checkJSONAssertions parses the body as JSON and checks all assertions. It returns the worst status
+// of all failed assertions (or green) and the reasons for the failures.
+func checkJSONAssertions(assertions []*JSONAssertion, body []byte) (Status, []string) {
+	var document any
+	err := json.Unmarshal(body, &document)
+	if err != nil {
+		return STATUS_RED, []string{"Response is not valid JSON: " + err.Error()}
+	}
+
+	status := STATUS_GREEN
+	failures := make([]string, 0)
+	for _, assertion := range assertions {
+		err := assertion.Check(document)
+		if err != nil {
+			status = worseStatus(status, assertion.Status)
+			failures = append(failures, err.Error())
+		}
+	}
+	return status, failures
+}
+
+// normalizeYAMLValue converts values decoded from YAML to the types used when decoding JSON, so
+// they can be compared directly
+func normalizeYAMLValue(value any) any {
+	switch v := value.(type) {
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case uint64:
+		return float64(v)
+	case float32:
+		return float64(v)
+	case map[any]any:
+		m := make(map[string]any, len(v))
+		for key, element := range v {
+			m[fmt.Sprint(key)] = normalizeYAMLValue(element)
+		}
+		return m
+	case []any:
+		s := make([]any, len(v))
+		for i, element := range v {
+			s[i] = normalizeYAMLValue(element)
+		}
+		return s
+	}
+	return value
+}
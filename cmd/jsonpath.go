@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// JSONPath is a parsed path expression. A subset of JSONPath is supported:
+//
+//	$              the root value
+//	.name ['name'] a property of an object
+//	[0] [-1]       an element of an array (negative indexes count from the end)
+//	.* [*]         all properties of an object or all elements of an array
+type JSONPath struct {
+	expression string
+	segments   []jsonPathSegment
+}
+
+type jsonPathSegment struct {
+	key      string
+	index    int
+	isIndex  bool
+	wildcard bool
+}
+
+func ParseJSONPath(expression string) (*JSONPath, error) {
+	path := &JSONPath{
+		expression: expression,
+		segments:   make([]jsonPathSegment, 0),
+	}
+
+	rest := strings.TrimSpace(expression)
+	if !strings.HasPrefix(rest, "$") {
+		return nil, fmt.Errorf("path %q must start with $", expression)
+	}
+	rest = rest[1:]
+
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end == -1 {
+				end = len(rest)
+			}
+			name := rest[:end]
+			rest = rest[end:]
+
+			if name == "" {
+				return nil, fmt.Errorf("path %q contains an empty property name", expression)
+			} else if name == "*" {
+				path.segments = append(path.segments, jsonPathSegment{wildcard: true})
+			} else {
+				path.segments = append(path.segments, jsonPathSegment{key: name})
+			}
+
+		case '[':
+			end := strings.Index(rest, "]")
+			if end == -1 {
+				return nil, fmt.Errorf("path %q contains an unclosed bracket", expression)
+			}
+			content := strings.TrimSpace(rest[1:end])
+			rest = rest[end+1:]
+
+			if content == "*" {
+				path.segments = append(path.segments, jsonPathSegment{wildcard: true})
+			} else if len(content) >= 2 && (content[0] == '\'' || content[0] == '"') && content[len(content)-1] == content[0] {
+				path.segments = append(path.segments, jsonPathSegment{key: content[1 : len(content)-1]})
+			} else {
+				index, err := strconv.Atoi(content)
+				if err != nil {
+					return nil, fmt.Errorf("path %q contains an invalid index: %s", expression, content)
+				}
+				path.segments = append(path.segments, jsonPathSegment{index: index, isIndex: true})
+			}
+
+		default:
+			return nil, fmt.Errorf("path %q contains an unexpected character at %q", expression, rest)
+		}
+	}
+
+	return path, nil
+}
+
+// Evaluate returns all values matching the path in the (decoded) JSON document
+func (p *JSONPath) Evaluate(document any) []any {
+	values := []any{document}
+
+	for _, segment := range p.segments {
+		next := make([]any, 0, len(values))
+		for _, value := range values {
+			switch v := value.(type) {
+			case map[string]any:
+				if segment.wildcard {
+					for _, property := range v {
+						next = append(next, property)
+					}
+				} else if property, ok := v[segment.key]; ok && !segment.isIndex {
+					next = append(next, property)
+				}
+
+			case []any:
+				if segment.wildcard {
+					next = append(next, v...)
+				} else if segment.isIndex {
+					index := segment.index
+					if index < 0 {
+						index += len(v)
+					}
+					if index >= 0 && index < len(v) {
+						next = append(next, v[index])
+					}
+				}
+			}
+		}
+		values = next
+	}
+
+	return values
+}
+
+func (p *JSONPath) String() string {
+	return p.expression
+}
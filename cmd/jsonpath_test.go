@@ -0,0 +1,167 @@
+package main
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"testing"
+)
+
+const jsonPathTestDocument = `{
+	"status": "UP",
+	"version": 3,
+	"dotted.name": true,
+	"components": {
+		"db": {"status": "UP", "latency": 12},
+		"cache": {"status": "DOWN", "latency": 250}
+	},
+	"items": [
+		{"name": "a", "tags": ["x", "y"]},
+		{"name": "b", "tags": []},
+		{"name": "c"}
+	],
+	"empty": [],
+	"nothing": null
+}`
+
+func TestParseJSONPathErrors(t *testing.T) {
+	for _, expression := range []string{
+		"",
+		"status",
+		"$status",
+		"$..status",
+		"$.",
+		"$.items[",
+		"$.items[x]",
+		"$.items[1.5]",
+		"$.items['a]",
+	} {
+		if _, err := ParseJSONPath(expression); err == nil {
+			t.Errorf("ParseJSONPath(%q): expected an error", expression)
+		}
+	}
+}
+
+func TestJSONPathEvaluate(t *testing.T) {
+	var document any
+	if err := json.Unmarshal([]byte(jsonPathTestDocument), &document); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path     string
+		expected []string // JSON encoded values, sorted
+	}{
+		{"$", nil}, // Checked separately
+		{"$.status", []string{`"UP"`}},
+		{" $.version ", []string{`3`}},
+		{"$['status']", []string{`"UP"`}},
+		{`$["dotted.name"]`, []string{`true`}},
+		{"$.components.db.latency", []string{`12`}},
+		{"$.components['cache'].status", []string{`"DOWN"`}},
+		{"$.components.*.status", []string{`"DOWN"`, `"UP"`}},
+		{"$.components[*].latency", []string{`12`, `250`}},
+		{"$.items[0].name", []string{`"a"`}},
+		{"$.items[2].name", []string{`"c"`}},
+		{"$.items[-1].name", []string{`"c"`}},
+		{"$.items[-3].name", []string{`"a"`}},
+		{"$.items[3]", []string{}},
+		{"$.items[-4]", []string{}},
+		{"$.items[*].name", []string{`"a"`, `"b"`, `"c"`}},
+		{"$.items.*.name", []string{`"a"`, `"b"`, `"c"`}},
+		{"$.items[*].tags[*]", []string{`"x"`, `"y"`}},
+		{"$.items[*].tags[0]", []string{`"x"`}},
+		{"$.empty[0]", []string{}},
+		{"$.empty[*]", []string{}},
+		{"$.nothing", []string{`null`}},
+		{"$.nothing.status", []string{}},
+		{"$.missing", []string{}},
+		{"$.status.length", []string{}},
+		{"$.items.name", []string{}},
+		{"$.components[0]", []string{}},
+	}
+
+	for _, test := range tests {
+		path, err := ParseJSONPath(test.path)
+		if err != nil {
+			t.Errorf("ParseJSONPath(%q): %s", test.path, err.Error())
+			continue
+		}
+
+		values := path.Evaluate(document)
+		if test.path == "$" {
+			if len(values) != 1 {
+				t.Errorf("%s: expected the document, got %d values", test.path, len(values))
+			}
+			continue
+		}
+
+		encoded := make([]string, 0, len(values))
+		for _, value := range values {
+			data, _ := json.Marshal(value)
+			encoded = append(encoded, string(data))
+		}
+		sort.Strings(encoded)
+		if strings.Join(encoded, ",") != strings.Join(test.expected, ",") {
+			t.Errorf("%s: expected [%s], got [%s]", test.path, strings.Join(test.expected, ","), strings.Join(encoded, ","))
+		}
+	}
+}
+
+func TestJSONAssertionCheck(t *testing.T) {
+	var document any
+	if err := json.Unmarshal([]byte(jsonPathTestDocument), &document); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		assertion JSONAssertion
+		ok        bool
+	}{
+		{JSONAssertion{Path: "$.status", Value: "UP"}, true},
+		{JSONAssertion{Path: "$.status", Value: "DOWN"}, false},
+		{JSONAssertion{Path: "$.version", Value: 3}, true},
+		{JSONAssertion{Path: "$.version", Operator: JSON_OP_NOT_EQUALS, Value: 2}, true},
+		{JSONAssertion{Path: "$.components.*.status", Value: "UP"}, false},
+		{JSONAssertion{Path: "$.components.*.latency", Operator: JSON_OP_LESS_THAN, Value: 500}, true},
+		{JSONAssertion{Path: "$.components.*.latency", Operator: JSON_OP_LESS_THAN, Value: 100}, false},
+		{JSONAssertion{Path: "$.version", Operator: JSON_OP_GREATER_THAN, Value: 2.5}, true},
+		{JSONAssertion{Path: "$.status", Operator: JSON_OP_GREATER_THAN, Value: 1}, false},
+		{JSONAssertion{Path: "$.items[0].tags", Operator: JSON_OP_CONTAINS, Value: "y"}, true},
+		{JSONAssertion{Path: "$.items[1].tags", Operator: JSON_OP_CONTAINS, Value: "y"}, false},
+		{JSONAssertion{Path: "$.components", Operator: JSON_OP_CONTAINS, Value: "db"}, true},
+		{JSONAssertion{Path: "$.status", Operator: JSON_OP_CONTAINS, Value: "U"}, true},
+		{JSONAssertion{Path: "$.items[*].name", Operator: JSON_OP_REGEX, Value: "^[a-c]$"}, true},
+		{JSONAssertion{Path: "$.version", Operator: JSON_OP_REGEX, Value: "^3$"}, true},
+		{JSONAssertion{Path: "$.nothing"}, true},
+		{JSONAssertion{Path: "$.missing"}, false},
+		{JSONAssertion{Path: "$.empty[*]", Value: "x"}, false},
+	}
+
+	for _, test := range tests {
+		assertion := test.assertion
+		if err := assertion.prepare(); err != nil {
+			t.Errorf("%s: %s", assertion.Path, err.Error())
+			continue
+		}
+		err := assertion.Check(document)
+		if (err == nil) != test.ok {
+			t.Errorf("%s %s %v: expected ok=%t, got %v", assertion.Path, assertion.Operator, assertion.Value, test.ok, err)
+		}
+	}
+}
+
+func TestJSONAssertionPrepareErrors(t *testing.T) {
+	for _, assertion := range []JSONAssertion{
+		{Path: "status"},
+		{Path: "$.status", Operator: "between"},
+		{Path: "$.version", Operator: JSON_OP_GREATER_THAN, Value: "3"},
+		{Path: "$.status", Operator: JSON_OP_REGEX, Value: "("},
+		{Path: "$.status", Operator: JSON_OP_REGEX, Value: 3},
+		{Path: "$.status", Value: "UP", Status: STATUS_GREEN},
+	} {
+		if err := assertion.prepare(); err == nil {
+			t.Errorf("%s %s %v: expected an error", assertion.Path, assertion.Operator, assertion.Value)
+		}
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 )
 
 var DebugMode = false // Global, set via CLI-Argument
@@ -26,7 +27,7 @@ func main() {
 		fFs = NewFrontendFS("frontend/")
 	}
 
-	server := NewServer(args.Port, fFs, args.CacheFile, config)
+	server := NewServer(args.Port, fFs, args.CacheFile, args.HistoryFile, args.StateFile, config)
 
 	cancelChan := make(chan os.Signal, 1)
 	signal.Notify(cancelChan, syscall.SIGTERM, syscall.SIGINT)
@@ -36,23 +37,28 @@ func main() {
 		server.Active = false
 	}()
 
-	/*
-		refreshChan := make(chan os.Signal, 1)
-		signal.Notify(refreshChan, syscall.SIGUSR1)
-		go func() {
-			for {
-				<-refreshChan
-				out("Reloading configuration...\n")
-				config, err := ReadConfiguration(args.ConfigFile)
-				if err != nil {
-					outError("Cannot reload configuration file %s: %s\n", args.ConfigFile, err.Error())
-				} else {
-					server.setConfiguration(config)
-					out("Configuration reloaded\n")
-				}
-			}
-		}()
-	*/
+	reloadConfiguration := func() {
+		out("Reloading configuration...\n")
+		err := server.ReloadConfiguration(args.ConfigFile)
+		if err != nil {
+			outError("Cannot reload configuration file %s: %s\n", args.ConfigFile, err.Error())
+		} else {
+			out("Configuration reloaded\n")
+		}
+	}
+
+	reloadChan := make(chan os.Signal, 1)
+	signal.Notify(reloadChan, reloadSignals...)
+	go func() {
+		for {
+			<-reloadChan
+			reloadConfiguration()
+		}
+	}()
+
+	if args.WatchConfig {
+		go WatchConfiguration(args.ConfigFile, 5*time.Second, reloadConfiguration)
+	}
 
 	_ = server.Run() // Runs until server.Active is set to false
 }
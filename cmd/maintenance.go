@@ -0,0 +1,294 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// MaintenanceWindow is a time range in which the endpoints of a group or a single endpoint are
+// expected to be unavailable. It is either a one-off window from start to end or a recurring
+// window that begins at every time matching the cron schedule and lasts for duration seconds.
+// Windows in the configuration belong to the group or endpoint they are defined in, windows
+// created via the API name their group (and optionally endpoint).
+type MaintenanceWindow struct {
+	ID       string    `yaml:"-" json:"id,omitempty"`
+	Group    string    `yaml:"-" json:"group,omitempty"`
+	Endpoint string    `yaml:"-" json:"endpoint,omitempty"`
+	Start    time.Time `yaml:"start,omitempty" json:"start,omitempty"`
+	End      time.Time `yaml:"end,omitempty" json:"end,omitempty"`
+	Schedule string    `yaml:"schedule,omitempty" json:"schedule,omitempty"`
+	Duration float64   `yaml:"duration,omitempty" json:"duration,omitempty"`
+	Reason   string    `yaml:"reason,omitempty" json:"reason,omitempty"`
+	Author   string    `yaml:"-" json:"author,omitempty"`
+	Created  time.Time `yaml:"-" json:"created,omitempty"`
+	cron     *CronSchedule
+}
+
+// prepare validates the window and parses the schedule
+func (w *MaintenanceWindow) prepare() error {
+	if w.Schedule != "" {
+		if w.Duration <= 0 {
+			return fmt.Errorf("duration must be set for scheduled maintenance")
+		}
+		cron, err := ParseCron(w.Schedule)
+		if err != nil {
+			return err
+		}
+		w.cron = cron
+		return nil
+	}
+
+	if w.Start.IsZero() || w.End.IsZero() {
+		return fmt.Errorf("start and end or schedule and duration must be set")
+	}
+	if !w.End.After(w.Start) {
+		return fmt.Errorf("end must be after start")
+	}
+	return nil
+}
+
+// Active returns whether the given time is within the window
+func (w *MaintenanceWindow) Active(t time.Time) bool {
+	if w.cron == nil {
+		return !t.Before(w.Start) && t.Before(w.End)
+	}
+
+	begin := w.cron.Next(t.Add(-time.Duration(w.Duration * float64(time.Second))))
+	return !begin.IsZero() && !begin.After(t)
+}
+
+// Expired returns whether the window will never be active again after the given time
+func (w *MaintenanceWindow) Expired(t time.Time) bool {
+	return w.cron == nil && !t.Before(w.End)
+}
+
+// appliesTo returns whether a window created via the API affects the endpoint
+func (w *MaintenanceWindow) appliesTo(group *Group, endpoint *Endpoint) bool {
+	return w.Group == group.Name && (w.Endpoint == "" || w.Endpoint == endpoint.Name)
+}
+
+// maintenanceFor returns the maintenance window the endpoint is in at the given time or nil.
+// Windows of parent groups apply to the endpoints of their subgroups.
+func (s *Server) maintenanceFor(group *Group, endpoint *Endpoint, t time.Time) *MaintenanceWindow {
+	for _, window := range endpoint.Maintenance {
+		if window.Active(t) {
+			return window
+		}
+	}
+	for _, window := range s.state.MaintenanceWindows() {
+		if window.appliesTo(group, endpoint) && window.Active(t) {
+			return window
+		}
+	}
+	return s.groupMaintenance(group, t)
+}
+
+// groupMaintenance returns the window for the whole group or one of its parents that is active at
+// the given time or nil
+func (s *Server) groupMaintenance(group *Group, t time.Time) *MaintenanceWindow {
+	windows := s.state.MaintenanceWindows()
+	for g := group; g != nil; g = g.parent {
+		for _, window := range g.Maintenance {
+			if window.Active(t) {
+				return window
+			}
+		}
+		for _, window := range windows {
+			if window.Group == g.Name && window.Endpoint == "" && window.Active(t) {
+				return window
+			}
+		}
+	}
+	return nil
+}
+
+// groupInMaintenance returns whether a window for the whole group or one of its parents is active
+// at the given time
+func (s *Server) groupInMaintenance(group *Group, t time.Time) bool {
+	return s.groupMaintenance(group, t) != nil
+}
+
+// MaintenanceRequest creates a maintenance window via the API. Instead of end, duration (in
+// seconds) can be given. Without start, the window starts immediately.
+type MaintenanceRequest struct {
+	Group    string    `json:"group"`
+	Endpoint string    `json:"endpoint"`
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
+	Schedule string    `json:"schedule"`
+	Duration float64   `json:"duration"`
+	Reason   string    `json:"reason"`
+}
+
+// MaintenanceResponse lists all maintenance windows from the configuration and the API
+type MaintenanceResponse struct {
+	Windows []*MaintenanceWindow `json:"windows"`
+}
+
+func (s *Server) handleMaintenance(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.respond(w, r, s.respondMaintenance())
+
+	case http.MethodPost, http.MethodDelete:
+		// Windows silence notifications, so they are only changed by known users
+		user := s.requestUser(r)
+		if user == "" {
+			s.respond(w, r, Error{
+				Code:    403,
+				Message: "Maintenance windows can only be changed by authenticated users",
+			})
+			return
+		}
+		if r.Method == http.MethodDelete {
+			s.respond(w, r, s.deleteMaintenance(r.URL.Query().Get("id"), user))
+			return
+		}
+
+		request := MaintenanceRequest{}
+		err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 64*1024)).Decode(&request)
+		if err != nil {
+			s.respond(w, r, Error{
+				Code:    400,
+				Message: "Invalid maintenance request: " + err.Error(),
+			})
+			return
+		}
+		s.respond(w, r, s.createMaintenance(request, user))
+
+	default:
+		s.respond(w, r, Error{
+			Code:    405,
+			Message: "Method not allowed",
+		})
+	}
+}
+
+func (s *Server) respondMaintenance() any {
+	now := time.Now()
+	windows := make([]*MaintenanceWindow, 0)
+	for _, group := range s.config().AllGroups() {
+		for _, window := range group.Maintenance {
+			configured := *window
+			configured.Group = group.Name
+			windows = append(windows, &configured)
+		}
+		for _, endpoint := range group.Endpoints {
+			for _, window := range endpoint.Maintenance {
+				configured := *window
+				configured.Group = group.Name
+				configured.Endpoint = endpoint.Name
+				windows = append(windows, &configured)
+			}
+		}
+	}
+	for _, window := range s.state.MaintenanceWindows() {
+		if !window.Expired(now) {
+			windows = append(windows, window)
+		}
+	}
+
+	return MaintenanceResponse{Windows: windows}
+}
+
+func (s *Server) createMaintenance(request MaintenanceRequest, user string) any {
+	group := s.groupByName(request.Group)
+	if group == nil || (request.Endpoint != "" && s.endpointByName(group, request.Endpoint) == nil) {
+		return Error{
+			Code:    400,
+			Message: "Invalid group/endpoint selection",
+		}
+	}
+
+	now := time.Now()
+	window := &MaintenanceWindow{
+		ID:       newMaintenanceID(),
+		Group:    request.Group,
+		Endpoint: request.Endpoint,
+		Start:    request.Start,
+		End:      request.End,
+		Schedule: request.Schedule,
+		Duration: request.Duration,
+		Reason:   request.Reason,
+		Author:   user,
+		Created:  now,
+	}
+	if window.Schedule == "" {
+		if window.Start.IsZero() {
+			window.Start = now
+		}
+		if window.End.IsZero() && window.Duration > 0 {
+			window.End = window.Start.Add(time.Duration(window.Duration * float64(time.Second)))
+		}
+	}
+
+	err := window.prepare()
+	if err != nil {
+		return Error{
+			Code:    400,
+			Message: "Invalid maintenance window: " + err.Error(),
+		}
+	}
+
+	err = s.state.AddMaintenance(window)
+	if err != nil {
+		outError("Cannot save maintenance window: %s\n", err.Error())
+	}
+	out("Maintenance %s for %s/%s created by %s: %s\n", window.ID, window.Group, window.Endpoint, window.Author, window.Reason)
+
+	s.recheck(group, window.Endpoint)
+	return window
+}
+
+func (s *Server) deleteMaintenance(id string, user string) any {
+	window, err := s.state.RemoveMaintenance(id)
+	if err != nil {
+		outError("Cannot save maintenance windows: %s\n", err.Error())
+	}
+	if window == nil {
+		return Error{
+			Code:    404,
+			Message: "Maintenance window not found",
+		}
+	}
+	out("Maintenance %s for %s/%s deleted by %s\n", window.ID, window.Group, window.Endpoint, user)
+
+	group := s.groupByName(window.Group)
+	if group != nil {
+		s.recheck(group, window.Endpoint)
+	}
+	return window
+}
+
+// recheck checks the endpoint (or all endpoints of the group if endpointName is empty) again right
+// away, so that changed maintenance windows are applied
+func (s *Server) recheck(group *Group, endpointName string) {
+	for _, endpoint := range group.Endpoints {
+		if endpointName != "" && endpoint.Name != endpointName {
+			continue
+		}
+
+		s.resultsMutex.Lock()
+		if result, ok := s.results[endpoint.ID]; ok {
+			outdated := *result
+			outdated.Updated = time.Time{}
+			s.results[endpoint.ID] = &outdated
+		}
+		s.resultsMutex.Unlock()
+
+		endpoint := endpoint
+		s.workers.Submit(endpointHost(group, endpoint), func() {
+			s.updateEndpoint(group, endpoint)
+		})
+	}
+}
+
+func newMaintenanceID() string {
+	id := make([]byte, 8)
+	_, _ = rand.Read(id)
+	return hex.EncodeToString(id)
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// metricsWriter writes metrics in the Prometheus text exposition format
+type metricsWriter struct {
+	buffer bytes.Buffer
+}
+
+// gauge writes the header of a gauge metric. The samples have to follow directly.
+func (m *metricsWriter) gauge(name string, help string) {
+	fmt.Fprintf(&m.buffer, "# HELP %s %s\n# TYPE %s gauge\n", name, help, name)
+}
+
+// sample writes a value with labels given as name/value pairs
+func (m *metricsWriter) sample(name string, value float64, labels ...string) {
+	m.buffer.WriteString(name)
+	if len(labels) > 0 {
+		m.buffer.WriteByte('{')
+		for i := 0; i+1 < len(labels); i += 2 {
+			if i > 0 {
+				m.buffer.WriteByte(',')
+			}
+			fmt.Fprintf(&m.buffer, "%s=\"%s\"", labels[i], escapeLabelValue(labels[i+1]))
+		}
+		m.buffer.WriteByte('}')
+	}
+	m.buffer.WriteByte(' ')
+	m.buffer.WriteString(strconv.FormatFloat(value, 'g', -1, 64))
+	m.buffer.WriteByte('\n')
+}
+
+var labelValueReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabelValue(value string) string {
+	return labelValueReplacer.Replace(value)
+}
+
+type endpointMetric struct {
+	labels []string
+	result *Result
+}
+
+func (s *Server) handleMetricsRequest(w http.ResponseWriter, r *http.Request) {
+	authErr := s.authorized(r)
+	if authErr != nil {
+		w.Header().Set("Content-Type", "application/json")
+		s.respond(w, r, authErr)
+		return
+	}
+
+	config := s.config()
+
+	endpoints := make([]endpointMetric, 0)
+	s.resultsMutex.Lock()
+	for _, group := range config.AllGroups() {
+		for _, endpoint := range group.Endpoints {
+			result, ok := s.results[endpoint.ID]
+			if !ok {
+				continue
+			}
+
+			scheme := ""
+			uri, err := EndpointURL(group, endpoint)
+			if err == nil {
+				scheme = uri.Scheme
+			}
+
+			// Copy, as results of inactive endpoints are changed in place
+			resultCopy := *result
+			endpoints = append(endpoints, endpointMetric{
+				labels: []string{"id", endpoint.ID, "group", group.Name, "endpoint", endpoint.Name, "category", group.Category, "scheme", scheme},
+				result: &resultCopy,
+			})
+		}
+	}
+	s.resultsMutex.Unlock()
+
+	m := &metricsWriter{}
+
+	m.gauge("mistatusboard_endpoint_status", "Status of the endpoint (0: inactive/maintenance/unknown, 1: green, 2: yellow, 3: red/blocked)")
+	for _, e := range endpoints {
+		m.sample("mistatusboard_endpoint_status", float64(statusSeverity(e.result.Status)), e.labels...)
+	}
+
+	m.gauge("mistatusboard_endpoint_code", "Response code of the last check of the endpoint")
+	for _, e := range endpoints {
+		m.sample("mistatusboard_endpoint_code", float64(e.result.Code), e.labels...)
+	}
+
+	m.gauge("mistatusboard_endpoint_request_duration_seconds", "Duration of the last check of the endpoint")
+	for _, e := range endpoints {
+		m.sample("mistatusboard_endpoint_request_duration_seconds", e.result.RequestDuration, e.labels...)
+	}
+
+	m.gauge("mistatusboard_endpoint_updated_timestamp_seconds", "Time of the last check of the endpoint")
+	for _, e := range endpoints {
+		m.sample("mistatusboard_endpoint_updated_timestamp_seconds", float64(e.result.Updated.UnixMilli())/1000, e.labels...)
+	}
+
+	m.gauge("mistatusboard_update_all_groups_duration_seconds", "Duration of the last update of all groups")
+	m.sample("mistatusboard_update_all_groups_duration_seconds", time.Duration(s.lastUpdateAllDuration.Load()).Seconds())
+
+	m.gauge("mistatusboard_scheduler_lag_seconds", "How late the last scheduled check was started")
+	m.sample("mistatusboard_scheduler_lag_seconds", s.scheduler.Lag().Seconds())
+
+	m.gauge("mistatusboard_probes_in_flight", "Number of checks currently running")
+	m.sample("mistatusboard_probes_in_flight", float64(s.probesInFlight.Load()))
+
+	m.gauge("mistatusboard_check_queue_depth", "Number of due checks waiting for a free worker")
+	m.sample("mistatusboard_check_queue_depth", float64(s.workers.QueueDepth()))
+
+	if s.resultsCacheFile != nil {
+		m.gauge("mistatusboard_cache_write_age_seconds", "Seconds since the results were last written to the cache file (-1 if never)")
+		lastWrite := s.lastCacheWrite.Load()
+		if lastWrite == 0 {
+			m.sample("mistatusboard_cache_write_age_seconds", -1)
+		} else {
+			m.sample("mistatusboard_cache_write_age_seconds", time.Since(time.Unix(0, lastWrite)).Seconds())
+		}
+	}
+
+	m.gauge("mistatusboard_uptime_seconds", "Seconds since the application was started")
+	m.sample("mistatusboard_uptime_seconds", time.Since(s.startTime).Seconds())
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.Write(m.buffer.Bytes())
+}
@@ -0,0 +1,262 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"text/template"
+	"time"
+)
+
+const (
+	notificationBodyExcerptLength  = 256
+	notificationDefaultRetries     = 3
+	notificationDefaultRetryDelay  = 5
+	notificationDefaultTimeout     = 10
+	notificationDefaultContentType = "application/json"
+	notificationQueueSize          = 100
+)
+
+type NotificationConfiguration struct {
+	Webhooks []*WebhookConfiguration `yaml:"webhooks"`
+}
+
+type WebhookConfiguration struct {
+	Name        string            `yaml:"name"`
+	URL         string            `yaml:"url"`
+	Groups      []string          `yaml:"groups"`
+	Headers     map[string]string `yaml:"headers"`
+	ContentType string            `yaml:"contentType"`
+	Template    string            `yaml:"template"`
+	Retries     int               `yaml:"retries"`
+	RetryDelay  float64           `yaml:"retryDelay"`
+	Timeout     float64           `yaml:"timeout"`
+	template    *template.Template
+	groups      map[string]bool
+}
+
+// Notification describes a status transition of an endpoint or a group
+type Notification struct {
+	Type      string    `json:"type"`
+	Group     string    `json:"group"`
+	Path      []string  `json:"path"` // Names of the parent groups and the group
+	Endpoint  string    `json:"endpoint,omitempty"`
+	OldStatus Status    `json:"old_status"`
+	NewStatus Status    `json:"new_status"`
+	Code      int       `json:"code,omitempty"`
+	Body      string    `json:"body,omitempty"`
+	Flapping  bool      `json:"flapping,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+var notificationTemplateFunctions = template.FuncMap{
+	"json": func(value any) (string, error) {
+		data, err := json.Marshal(value)
+		return string(data), err
+	},
+}
+
+// prepare validates the webhook configuration, sets defaults and parses the template
+func (w *WebhookConfiguration) prepare() error {
+	if w.URL == "" {
+		return fmt.Errorf("webhook %s: url must be set", w.Name)
+	}
+	if w.Name == "" {
+		w.Name = w.URL
+	}
+	if w.ContentType == "" {
+		w.ContentType = notificationDefaultContentType
+	}
+	if w.Retries < 0 {
+		w.Retries = 0
+	} else if w.Retries == 0 {
+		w.Retries = notificationDefaultRetries
+	}
+	if w.RetryDelay <= 0 {
+		w.RetryDelay = notificationDefaultRetryDelay
+	}
+	if w.Timeout <= 0 {
+		w.Timeout = notificationDefaultTimeout
+	}
+
+	if w.Template != "" {
+		tpl, err := template.New(w.Name).Funcs(notificationTemplateFunctions).Parse(w.Template)
+		if err != nil {
+			return fmt.Errorf("webhook %s: invalid template: %s", w.Name, err.Error())
+		}
+		w.template = tpl
+	}
+
+	w.groups = make(map[string]bool, len(w.Groups))
+	for _, g := range w.Groups {
+		w.groups[g] = true
+	}
+	return nil
+}
+
+// responsibleFor returns whether the notification is sent to this webhook. Webhooks for a group
+// also receive the notifications of its subgroups.
+func (w *WebhookConfiguration) responsibleFor(notification Notification) bool {
+	if len(w.groups) == 0 {
+		return true
+	}
+	for _, groupName := range notification.Path {
+		if w.groups[groupName] {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *WebhookConfiguration) payload(notification Notification) ([]byte, error) {
+	if w.template == nil {
+		return json.Marshal(notification)
+	}
+
+	buffer := bytes.Buffer{}
+	err := w.template.Execute(&buffer, notification)
+	return buffer.Bytes(), err
+}
+
+// Notifier sends notifications to the configured webhooks in the background
+type Notifier struct {
+	config atomic.Pointer[NotificationConfiguration]
+	queue  chan Notification
+	client *http.Client
+}
+
+func NewNotifier(config NotificationConfiguration) *Notifier {
+	n := &Notifier{
+		queue:  make(chan Notification, notificationQueueSize),
+		client: &http.Client{},
+	}
+	n.config.Store(&config)
+	go n.dispatch()
+	return n
+}
+
+func (n *Notifier) SetConfiguration(config NotificationConfiguration) {
+	n.config.Store(&config)
+}
+
+// Notify queues the notification. If the queue is full, the notification is dropped.
+func (n *Notifier) Notify(notification Notification) {
+	select {
+	case n.queue <- notification:
+	default:
+		outError("Notification queue full, dropping notification for %s %s/%s\n", notification.Type, notification.Group, notification.Endpoint)
+	}
+}
+
+// NotifyEndpoint queues a notification for an endpoint status transition
+func (n *Notifier) NotifyEndpoint(group *Group, endpoint *Endpoint, oldStatus Status, result *Result) {
+	body := result.Body
+	if len(body) > notificationBodyExcerptLength {
+		body = body[:notificationBodyExcerptLength]
+	}
+
+	n.Notify(Notification{
+		Type:      NOTIFICATION_TYPE_ENDPOINT,
+		Group:     group.Name,
+		Path:      group.path(),
+		Endpoint:  endpoint.Name,
+		OldStatus: oldStatus,
+		NewStatus: result.Status,
+		Code:      result.Code,
+		Body:      string(body),
+		Flapping:  result.Flapping,
+		Timestamp: result.Updated,
+	})
+}
+
+// NotifyGroup queues a notification for a group status transition
+func (n *Notifier) NotifyGroup(group *Group, oldStatus Status, newStatus Status) {
+	n.Notify(Notification{
+		Type:      NOTIFICATION_TYPE_GROUP,
+		Group:     group.Name,
+		Path:      group.path(),
+		OldStatus: oldStatus,
+		NewStatus: newStatus,
+		Timestamp: time.Now(),
+	})
+}
+
+func (n *Notifier) dispatch() {
+	for notification := range n.queue {
+		for _, webhook := range n.config.Load().Webhooks {
+			if webhook.responsibleFor(notification) {
+				go n.send(webhook, notification)
+			}
+		}
+	}
+}
+
+// send posts the notification to the webhook and retries with exponential backoff on failure
+func (n *Notifier) send(webhook *WebhookConfiguration, notification Notification) {
+	payload, err := webhook.payload(notification)
+	if err != nil {
+		outError("Cannot create notification for webhook %s: %s\n", webhook.Name, err.Error())
+		return
+	}
+
+	delay := time.Duration(webhook.RetryDelay * float64(time.Second))
+	for attempt := 0; attempt <= webhook.Retries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(delay)
+			delay *= 2
+		}
+
+		err = n.post(webhook, payload)
+		if err == nil {
+			outDebug("Notification sent to webhook %s: %s/%s %s -> %s\n", webhook.Name, notification.Group, notification.Endpoint, notification.OldStatus, notification.NewStatus)
+			return
+		}
+		outDebug("Notification to webhook %s failed (attempt %d): %s\n", webhook.Name, attempt+1, err.Error())
+	}
+
+	outError("Cannot send notification to webhook %s: %s\n", webhook.Name, err.Error())
+}
+
+func (n *Notifier) post(webhook *WebhookConfiguration, payload []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(webhook.Timeout*float64(time.Second)))
+	defer cancel()
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", webhook.ContentType)
+	for name, value := range webhook.Headers {
+		request.Header.Set(name, value)
+	}
+
+	response, err := n.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	_, _ = io.Copy(io.Discard, response.Body)
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return fmt.Errorf("unexpected response status %s", response.Status)
+	}
+	return nil
+}
+
+// isTransition returns whether a change between both status should be notified. Changes from or
+// to inactive and the first result of an endpoint are not notified, neither are changes to blocked
+// or maintenance. Leaving maintenance or blocked is only notified if the status is not green
+// afterwards, since the change before was not.
+func isTransition(oldStatus Status, newStatus Status) bool {
+	switch {
+	case oldStatus == newStatus, newStatus == STATUS_BLOCKED, statusSeverity(newStatus) == 0:
+		return false
+	case oldStatus == STATUS_MAINTENANCE, oldStatus == STATUS_BLOCKED:
+		return newStatus != STATUS_GREEN
+	}
+	return statusSeverity(oldStatus) > 0
+}
@@ -0,0 +1,38 @@
+package main
+
+import "testing"
+
+func TestIsTransition(t *testing.T) {
+	tests := []struct {
+		oldStatus Status
+		newStatus Status
+		expected  bool
+	}{
+		{STATUS_GREEN, STATUS_GREEN, false},
+		{STATUS_GREEN, STATUS_RED, true},
+		{STATUS_RED, STATUS_YELLOW, true},
+		{STATUS_YELLOW, STATUS_GREEN, true},
+		{"", STATUS_RED, false},
+		{STATUS_INACTIVE, STATUS_RED, false},
+		{STATUS_RED, STATUS_INACTIVE, false},
+
+		// Entering maintenance or blocked is silent, leaving it only when still failing
+		{STATUS_RED, STATUS_MAINTENANCE, false},
+		{STATUS_MAINTENANCE, STATUS_GREEN, false},
+		{STATUS_MAINTENANCE, STATUS_YELLOW, true},
+		{STATUS_MAINTENANCE, STATUS_RED, true},
+		{STATUS_MAINTENANCE, STATUS_BLOCKED, false},
+		{STATUS_GREEN, STATUS_BLOCKED, false},
+		{STATUS_RED, STATUS_BLOCKED, false},
+		{STATUS_BLOCKED, STATUS_GREEN, false},
+		{STATUS_BLOCKED, STATUS_YELLOW, true},
+		{STATUS_BLOCKED, STATUS_RED, true},
+		{STATUS_BLOCKED, STATUS_MAINTENANCE, false},
+	}
+
+	for _, test := range tests {
+		if actual := isTransition(test.oldStatus, test.newStatus); actual != test.expected {
+			t.Errorf("%q -> %q: expected %v, got %v", test.oldStatus, test.newStatus, test.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,304 @@
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	icmpV4EchoRequest = 8
+	icmpV4EchoReply   = 0
+	icmpV6EchoRequest = 128
+	icmpV6EchoReply   = 129
+
+	pingDefaultCount   = 1
+	pingDefaultTimeout = 5
+)
+
+// pingID makes the ID of concurrent pings unique, raw sockets receive the replies of all of them
+var pingID atomic.Uint32
+
+func init() {
+	pingID.Store(uint32(os.Getpid()))
+	RegisterProber("ping", ProberFunc(probePing))
+}
+
+type PingConfiguration struct {
+	Count   int     `yaml:"count,omitempty" json:"count,omitempty"`
+	Timeout float64 `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+	MaxLoss float64 `yaml:"maxLoss,omitempty" json:"maxLoss,omitempty"`
+	MaxRTT  float64 `yaml:"maxRtt,omitempty" json:"maxRtt,omitempty"`
+}
+
+type PingStatistics struct {
+	Address  string
+	Sent     int
+	Received int
+	RTTs     []time.Duration
+}
+
+// Loss returns the packet loss in percent
+func (p *PingStatistics) Loss() float64 {
+	if p.Sent == 0 {
+		return 100
+	}
+	return float64(p.Sent-p.Received) / float64(p.Sent) * 100
+}
+
+func (p *PingStatistics) AverageRTT() time.Duration {
+	if len(p.RTTs) == 0 {
+		return 0
+	}
+	var sum time.Duration
+	for _, rtt := range p.RTTs {
+		sum += rtt
+	}
+	return sum / time.Duration(len(p.RTTs))
+}
+
+func (p *PingStatistics) String() string {
+	text := fmt.Sprintf("%s: %d packets transmitted, %d received, %.1f%% packet loss", p.Address, p.Sent, p.Received, p.Loss())
+	if len(p.RTTs) > 0 {
+		shortest, longest := p.RTTs[0], p.RTTs[0]
+		for _, rtt := range p.RTTs {
+			if rtt < shortest {
+				shortest = rtt
+			}
+			if rtt > longest {
+				longest = rtt
+			}
+		}
+		text += fmt.Sprintf("\nrtt min/avg/max = %s/%s/%s", shortest, p.AverageRTT(), longest)
+	}
+	return text
+}
+
+// Ping sends count ICMP echo requests to host and waits up to timeout for each reply. Unprivileged
+// datagram sockets are used where the OS allows it, otherwise raw sockets are tried.
+func Ping(ctx context.Context, host string, count int, timeout time.Duration) (*PingStatistics, error) {
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("no address found for %s", host)
+	}
+
+	// Prefer IPv4 addresses
+	ip := addrs[0].IP
+	for _, addr := range addrs {
+		if addr.IP.To4() != nil {
+			ip = addr.IP
+			break
+		}
+	}
+	ipv6 := ip.To4() == nil
+
+	conn, datagram, err := listenICMP(ipv6)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	var dst net.Addr = &net.IPAddr{IP: ip}
+	if datagram {
+		dst = &net.UDPAddr{IP: ip}
+	}
+
+	stats := &PingStatistics{
+		Address: ip.String(),
+		RTTs:    make([]time.Duration, 0, count),
+	}
+
+	id := int(pingID.Add(1) & 0xffff)
+	buffer := make([]byte, 1500)
+	for seq := 1; seq <= count; seq++ {
+		if ctx.Err() != nil {
+			return stats, ctx.Err()
+		}
+
+		deadline := time.Now().Add(timeout)
+		if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+			deadline = ctxDeadline
+		}
+
+		sent := time.Now()
+		_, err = conn.WriteTo(icmpEchoRequest(ipv6, id, seq), dst)
+		if err != nil {
+			return stats, err
+		}
+		stats.Sent++
+
+		err = conn.SetReadDeadline(deadline)
+		if err != nil {
+			return stats, err
+		}
+
+		for {
+			n, peer, err := conn.ReadFrom(buffer)
+			if err != nil {
+				var netErr net.Error
+				if errors.As(err, &netErr) && netErr.Timeout() {
+					break
+				}
+				return stats, err
+			}
+
+			if !addrIP(peer).Equal(ip) {
+				// Reply to another ping
+				continue
+			}
+
+			// Datagram sockets get their ID rewritten by the kernel and only receive their own replies
+			if isICMPEchoReply(buffer[:n], ipv6, id, seq, !datagram) {
+				stats.Received++
+				stats.RTTs = append(stats.RTTs, time.Since(sent))
+				break
+			}
+		}
+	}
+
+	return stats, nil
+}
+
+// addrIP returns the IP address of a peer of an ICMP socket or nil
+func addrIP(addr net.Addr) net.IP {
+	switch a := addr.(type) {
+	case *net.IPAddr:
+		return a.IP
+	case *net.UDPAddr:
+		return a.IP
+	}
+	return nil
+}
+
+func listenICMP(ipv6 bool) (net.PacketConn, bool, error) {
+	conn, err := listenICMPDatagram(ipv6)
+	if err == nil {
+		return conn, true, nil
+	}
+	outDebug("Unprivileged ICMP socket not available (%s), trying raw socket\n", err.Error())
+
+	network, address := "ip4:icmp", "0.0.0.0"
+	if ipv6 {
+		network, address = "ip6:ipv6-icmp", "::"
+	}
+	conn, rawErr := net.ListenPacket(network, address)
+	if rawErr != nil {
+		return nil, false, fmt.Errorf("cannot open ICMP socket: %s / %s", err.Error(), rawErr.Error())
+	}
+	return conn, false, nil
+}
+
+func icmpEchoRequest(ipv6 bool, id int, seq int) []byte {
+	msg := make([]byte, 8+16)
+	msg[0] = icmpV4EchoRequest
+	if ipv6 {
+		msg[0] = icmpV6EchoRequest
+	}
+	binary.BigEndian.PutUint16(msg[4:], uint16(id))
+	binary.BigEndian.PutUint16(msg[6:], uint16(seq))
+	copy(msg[8:], "miStatusBoard...")
+
+	if !ipv6 {
+		// The kernel calculates the checksum for ICMPv6
+		binary.BigEndian.PutUint16(msg[2:], icmpChecksum(msg))
+	}
+	return msg
+}
+
+func isICMPEchoReply(msg []byte, ipv6 bool, id int, seq int, checkID bool) bool {
+	// Some systems (e.g. darwin) deliver the IPv4 header with datagram sockets
+	if !ipv6 && len(msg) >= 20 && msg[0]>>4 == 4 {
+		headerLength := int(msg[0]&0x0f) * 4
+		if len(msg) < headerLength {
+			return false
+		}
+		msg = msg[headerLength:]
+	}
+
+	if len(msg) < 8 {
+		return false
+	}
+
+	replyType := byte(icmpV4EchoReply)
+	if ipv6 {
+		replyType = icmpV6EchoReply
+	}
+	if msg[0] != replyType {
+		return false
+	}
+	if checkID && int(binary.BigEndian.Uint16(msg[4:])) != id {
+		return false
+	}
+	return int(binary.BigEndian.Uint16(msg[6:])) == seq&0xffff
+}
+
+func icmpChecksum(msg []byte) uint16 {
+	var sum uint32
+	for i := 0; i+1 < len(msg); i += 2 {
+		sum += uint32(msg[i])<<8 | uint32(msg[i+1])
+	}
+	if len(msg)%2 == 1 {
+		sum += uint32(msg[len(msg)-1]) << 8
+	}
+	for sum>>16 != 0 {
+		sum = (sum & 0xffff) + (sum >> 16)
+	}
+	return ^uint16(sum)
+}
+
+// probePing checks the host of the endpoint URL via ICMP echo requests
+func probePing(ctx context.Context, group *Group, endpoint *Endpoint) *Result {
+	uri, err := EndpointURL(group, endpoint)
+	if err != nil {
+		return errorResult(err)
+	}
+
+	config := endpoint.Ping
+	count := config.Count
+	if count <= 0 {
+		count = pingDefaultCount
+	}
+	timeout := config.Timeout
+	if timeout <= 0 {
+		timeout = pingDefaultTimeout
+	}
+
+	stats, err := Ping(ctx, uri.Hostname(), count, time.Duration(timeout*float64(time.Second)))
+	if err != nil {
+		return errorResult(err)
+	}
+
+	result := &Result{
+		ContentType:     "text/plain",
+		RequestDuration: stats.AverageRTT().Seconds(),
+	}
+
+	problems := make([]string, 0)
+	if stats.Received == 0 {
+		problems = append(problems, "no reply received")
+	}
+	if config.MaxLoss > 0 && stats.Loss() > config.MaxLoss {
+		problems = append(problems, fmt.Sprintf("packet loss %.1f%% exceeds %.1f%%", stats.Loss(), config.MaxLoss))
+	}
+	if config.MaxRTT > 0 && stats.Received > 0 && result.RequestDuration > config.MaxRTT {
+		problems = append(problems, fmt.Sprintf("average rtt %s exceeds %gs", stats.AverageRTT(), config.MaxRTT))
+	}
+
+	if len(problems) > 0 {
+		result.Status = STATUS_RED
+		result.Body = []byte(strings.Join(problems, "\n") + "\n-----\n" + stats.String())
+	} else {
+		result.Status = STATUS_GREEN
+		result.Body = []byte(stats.String())
+	}
+	return result
+}
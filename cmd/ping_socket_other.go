@@ -0,0 +1,12 @@
+//go:build !linux && !darwin
+
+package main
+
+import (
+	"errors"
+	"net"
+)
+
+func listenICMPDatagram(ipv6 bool) (net.PacketConn, error) {
+	return nil, errors.New("unprivileged ICMP sockets are not supported on this platform")
+}
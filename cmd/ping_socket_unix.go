@@ -0,0 +1,36 @@
+//go:build linux || darwin
+
+package main
+
+import (
+	"net"
+	"os"
+	"syscall"
+)
+
+// listenICMPDatagram opens an unprivileged ICMP socket (SOCK_DGRAM). On linux this requires the
+// group of the process to be within net.ipv4.ping_group_range.
+func listenICMPDatagram(ipv6 bool) (net.PacketConn, error) {
+	family, proto := syscall.AF_INET, syscall.IPPROTO_ICMP
+	var sockaddr syscall.Sockaddr = &syscall.SockaddrInet4{}
+	if ipv6 {
+		family, proto = syscall.AF_INET6, syscall.IPPROTO_ICMPV6
+		sockaddr = &syscall.SockaddrInet6{}
+	}
+
+	fd, err := syscall.Socket(family, syscall.SOCK_DGRAM, proto)
+	if err != nil {
+		return nil, os.NewSyscallError("socket", err)
+	}
+
+	err = syscall.Bind(fd, sockaddr)
+	if err != nil {
+		syscall.Close(fd)
+		return nil, os.NewSyscallError("bind", err)
+	}
+
+	file := os.NewFile(uintptr(fd), "icmp")
+	defer file.Close()
+
+	return net.FilePacketConn(file)
+}
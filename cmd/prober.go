@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// Prober checks a single endpoint and returns the result of the check. The context is cancelled
+// when the check takes too long. Probers are registered per URL scheme via RegisterProber.
+type Prober interface {
+	Probe(ctx context.Context, group *Group, endpoint *Endpoint) *Result
+}
+
+// ProberFunc allows using ordinary functions as Prober
+type ProberFunc func(ctx context.Context, group *Group, endpoint *Endpoint) *Result
+
+func (f ProberFunc) Probe(ctx context.Context, group *Group, endpoint *Endpoint) *Result {
+	return f(ctx, group, endpoint)
+}
+
+var (
+	probers      = make(map[string]Prober)
+	probersMutex sync.RWMutex
+)
+
+// RegisterProber makes the prober responsible for all endpoints with the given URL scheme.
+// A prober registered earlier for the same scheme is replaced.
+func RegisterProber(scheme string, prober Prober) {
+	probersMutex.Lock()
+	defer probersMutex.Unlock()
+	probers[strings.ToLower(scheme)] = prober
+}
+
+// ProberFor returns the prober for the given URL scheme or nil if there is none
+func ProberFor(scheme string) Prober {
+	probersMutex.RLock()
+	defer probersMutex.RUnlock()
+	return probers[strings.ToLower(scheme)]
+}
+
+// EndpointURL resolves the endpoint URL against the URL of its group
+func EndpointURL(group *Group, endpoint *Endpoint) (*url.URL, error) {
+	baseUri, err := url.Parse(group.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base URL for group %s: %s. Error: %s", group.Name, group.URL, err.Error())
+	}
+
+	uri, err := baseUri.Parse(endpoint.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL for endpoint %s in group %s: %s. Error: %s", endpoint.Name, group.Name, endpoint.URL, err.Error())
+	}
+
+	return uri, nil
+}
+
+// endpointHost returns the host name of the endpoint URL or an empty string if it is invalid
+func endpointHost(group *Group, endpoint *Endpoint) string {
+	uri, err := EndpointURL(group, endpoint)
+	if err != nil {
+		return ""
+	}
+	return uri.Hostname()
+}
+
+// errorResult creates a red result containing the error message as body
+func errorResult(err error) *Result {
+	return &Result{
+		Status:      STATUS_RED,
+		ContentType: "text/plain",
+		Body:        []byte(err.Error()),
+	}
+}
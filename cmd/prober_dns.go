@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DNSOptions configure "dns://" endpoints. The URL contains the resolver address and the queried
+// name, e.g. "dns://1.1.1.1:53/example.com?type=MX". Without resolver ("dns:///example.com") the
+// first nameserver of the system is queried. The name is always fully qualified.
+type DNSOptions struct {
+	Type            string     `yaml:"type,omitempty" json:"type,omitempty"`
+	Expect          StringList `yaml:"expect,omitempty" json:"expect,omitempty"`
+	Contains        StringList `yaml:"contains,omitempty" json:"contains,omitempty"`
+	MinCount        int        `yaml:"minCount,omitempty" json:"minCount,omitempty"`
+	MaxResponseTime float64    `yaml:"maxResponseTime,omitempty" json:"maxResponseTime,omitempty"`
+}
+
+// prepare validates the record type
+func (o *DNSOptions) prepare() error {
+	o.Type = strings.ToUpper(o.Type)
+	if _, ok := dnsTypeCodes[o.Type]; o.Type != "" && !ok {
+		return fmt.Errorf("type: unsupported record type %s", o.Type)
+	}
+	return nil
+}
+
+func init() {
+	RegisterProber("dns", ProberFunc(probeDNS))
+}
+
+func probeDNS(ctx context.Context, group *Group, endpoint *Endpoint) *Result {
+	uri, err := EndpointURL(group, endpoint)
+	if err != nil {
+		return errorResult(err)
+	}
+
+	options := endpoint.DNS
+	recordType := options.Type
+	if recordType == "" {
+		recordType = uri.Query().Get("type")
+	}
+	if recordType == "" {
+		recordType = "A"
+	}
+	recordType = strings.ToUpper(recordType)
+
+	name := strings.TrimPrefix(uri.Path, "/")
+	if name == "" {
+		return errorResult(fmt.Errorf("no name to query in %s", uri.String()))
+	}
+
+	server := systemNameserver()
+	if uri.Host != "" {
+		server = uri.Host
+		if uri.Port() == "" {
+			server = net.JoinHostPort(uri.Hostname(), "53")
+		}
+	}
+
+	startTime := time.Now()
+	answers, err := DNSQuery(ctx, server, recordType, name)
+	duration := time.Since(startTime).Seconds()
+	if err != nil {
+		result := errorResult(err)
+		result.RequestDuration = duration
+		return result
+	}
+	sort.Strings(answers)
+
+	result := &Result{
+		Status:          STATUS_GREEN,
+		ContentType:     "text/plain",
+		Body:            []byte(fmt.Sprintf("%s %s\n\n%s\n", recordType, name, strings.Join(answers, "\n"))),
+		RequestDuration: duration,
+	}
+
+	failures := checkDNSAnswers(options, recordType, answers)
+	if options.MaxResponseTime > 0 && duration > options.MaxResponseTime {
+		failures = append(failures, fmt.Sprintf("response time %.3fs exceeds %gs", duration, options.MaxResponseTime))
+	}
+	if len(failures) > 0 {
+		result.Status = STATUS_RED
+		result.FailedAssertions = failures
+	}
+
+	return result
+}
+
+// checkDNSAnswers returns the reasons why the answers do not fulfill the expectations
+func checkDNSAnswers(options DNSOptions, recordType string, answers []string) []string {
+	failures := make([]string, 0)
+
+	normalized := make(map[string]bool, len(answers))
+	for _, answer := range answers {
+		normalized[normalizeDNSAnswer(recordType, answer)] = true
+	}
+
+	if len(options.Expect) > 0 {
+		expected := make(map[string]bool, len(options.Expect))
+		for _, e := range options.Expect {
+			expected[normalizeDNSAnswer(recordType, e)] = true
+		}
+		equal := len(expected) == len(normalized)
+		for e := range expected {
+			equal = equal && normalized[e]
+		}
+		if !equal {
+			failures = append(failures, fmt.Sprintf("answers are not [%s]", strings.Join(options.Expect, ", ")))
+		}
+	}
+
+	for _, c := range options.Contains {
+		if !normalized[normalizeDNSAnswer(recordType, c)] {
+			failures = append(failures, fmt.Sprintf("answers do not contain %s", c))
+		}
+	}
+
+	if len(answers) < options.MinCount {
+		failures = append(failures, fmt.Sprintf("%d answers, expected at least %d", len(answers), options.MinCount))
+	}
+
+	return failures
+}
+
+// normalizeDNSAnswer makes host names comparable regardless of case and trailing dots
+func normalizeDNSAnswer(recordType string, answer string) string {
+	if recordType == "TXT" {
+		return answer
+	}
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(answer)), ".")
+}
@@ -0,0 +1,283 @@
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type testDNSRecord struct {
+	name  string
+	rtype uint16
+	data  []byte
+}
+
+// testDNSServer is a local stand-in DNS server that answers from a fixed set of records via UDP
+// and TCP. Names are matched exactly (fully qualified, lower case).
+type testDNSServer struct {
+	records  []testDNSRecord
+	truncate atomic.Bool // Answer UDP queries with the truncated flag only
+
+	mutex   sync.Mutex
+	queries []string
+	udp     net.PacketConn
+	tcp     net.Listener
+}
+
+func startTestDNSServer(t *testing.T, records []testDNSRecord) *testDNSServer {
+	t.Helper()
+
+	udp, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tcp, err := net.Listen("tcp", udp.LocalAddr().String())
+	if err != nil {
+		udp.Close()
+		t.Skipf("cannot listen on TCP with the port of the UDP socket: %s", err.Error())
+	}
+
+	server := &testDNSServer{records: records, udp: udp, tcp: tcp}
+	t.Cleanup(func() {
+		udp.Close()
+		tcp.Close()
+	})
+
+	go func() {
+		buffer := make([]byte, 512)
+		for {
+			n, addr, err := udp.ReadFrom(buffer)
+			if err != nil {
+				return
+			}
+			_, _ = udp.WriteTo(server.answer(buffer[:n], server.truncate.Load()), addr)
+		}
+	}()
+
+	go func() {
+		for {
+			conn, err := tcp.Accept()
+			if err != nil {
+				return
+			}
+			length := make([]byte, 2)
+			if _, err := io.ReadFull(conn, length); err == nil {
+				query := make([]byte, binary.BigEndian.Uint16(length))
+				if _, err := io.ReadFull(conn, query); err == nil {
+					response := server.answer(query, false)
+					_, _ = conn.Write(append(binary.BigEndian.AppendUint16(nil, uint16(len(response))), response...))
+				}
+			}
+			conn.Close()
+		}
+	}()
+
+	return server
+}
+
+func (s *testDNSServer) address() string {
+	return s.udp.LocalAddr().String()
+}
+
+func (s *testDNSServer) answer(query []byte, truncate bool) []byte {
+	name, next, err := readDNSName(query, dnsHeaderLength)
+	if err != nil {
+		return nil
+	}
+	qtype := binary.BigEndian.Uint16(query[next:])
+
+	s.mutex.Lock()
+	s.queries = append(s.queries, name)
+	s.mutex.Unlock()
+
+	response := append([]byte{}, query[:next+4]...)
+	flags := uint16(dnsFlagResponse | dnsFlagRecursion)
+	if truncate {
+		binary.BigEndian.PutUint16(response[2:], flags|dnsFlagTruncated)
+		return response
+	}
+
+	known := false
+	answers := 0
+	for _, record := range s.records {
+		if record.name != strings.ToLower(name) {
+			continue
+		}
+		known = true
+		if record.rtype != qtype && record.rtype != dnsTypeCNAME {
+			continue
+		}
+		// The name is compressed as pointer to the question
+		response = append(response, 0xc0, dnsHeaderLength)
+		response = binary.BigEndian.AppendUint16(response, record.rtype)
+		response = binary.BigEndian.AppendUint16(response, dnsClassIN)
+		response = binary.BigEndian.AppendUint32(response, 60)
+		response = binary.BigEndian.AppendUint16(response, uint16(len(record.data)))
+		response = append(response, record.data...)
+		answers++
+	}
+	if !known {
+		flags |= dnsRcodeNXDomain
+	}
+	binary.BigEndian.PutUint16(response[2:], flags)
+	binary.BigEndian.PutUint16(response[6:], uint16(answers))
+	return response
+}
+
+func testDNSName(name string) []byte {
+	data, _ := appendDNSName(nil, name)
+	return data
+}
+
+func testDNSRecords() []testDNSRecord {
+	u16 := func(values ...uint16) []byte {
+		data := make([]byte, 0)
+		for _, v := range values {
+			data = binary.BigEndian.AppendUint16(data, v)
+		}
+		return data
+	}
+
+	return []testDNSRecord{
+		{"example.test.", dnsTypeA, net.ParseIP("192.0.2.1").To4()},
+		{"example.test.", dnsTypeA, net.ParseIP("192.0.2.2").To4()},
+		{"example.test.", dnsTypeAAAA, net.ParseIP("2001:db8::1")},
+		{"example.test.", dnsTypeMX, append(u16(10), testDNSName("mail.example.test")...)},
+		{"example.test.", dnsTypeMX, append(u16(20), testDNSName("backup.example.test")...)},
+		{"example.test.", dnsTypeNS, testDNSName("ns1.example.test")},
+		{"example.test.", dnsTypeTXT, []byte("\x05v=spf\x061 -all")},
+		{"_http._tcp.example.test.", dnsTypeSRV, append(u16(1, 5, 8080), testDNSName("web.example.test")...)},
+		{"www.example.test.", dnsTypeCNAME, testDNSName("example.test")},
+		// The A record of the CNAME target is part of the answer, as recursive resolvers send it
+		{"www.example.test.", dnsTypeA, net.ParseIP("192.0.2.1").To4()},
+	}
+}
+
+func TestDNSQuery(t *testing.T) {
+	server := startTestDNSServer(t, testDNSRecords())
+
+	tests := []struct {
+		recordType string
+		name       string
+		expected   []string
+		err        bool
+	}{
+		{"A", "example.test", []string{"192.0.2.1", "192.0.2.2"}, false},
+		{"A", "example.test.", []string{"192.0.2.1", "192.0.2.2"}, false},
+		{"AAAA", "example.test", []string{"2001:db8::1"}, false},
+		{"MX", "example.test", []string{"10 mail.example.test.", "20 backup.example.test."}, false},
+		{"NS", "example.test", []string{"ns1.example.test."}, false},
+		{"TXT", "example.test", []string{"v=spf1 -all"}, false},
+		{"SRV", "_http._tcp.example.test", []string{"1 5 8080 web.example.test."}, false},
+		{"CNAME", "www.example.test", []string{"example.test."}, false},
+		{"A", "www.example.test", []string{"192.0.2.1"}, false},
+		// No CNAME record: no answer instead of the queried name
+		{"CNAME", "example.test", []string{}, false},
+		{"A", "_http._tcp.example.test", []string{}, false},
+		{"A", "missing.test", nil, true},
+		{"A", "invalid..test", nil, true},
+		{"PTR", "example.test", nil, true},
+	}
+
+	for _, test := range tests {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		answers, err := DNSQuery(ctx, server.address(), test.recordType, test.name)
+		cancel()
+
+		if test.err {
+			if err == nil {
+				t.Errorf("%s %s: expected an error, got %v", test.recordType, test.name, answers)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s %s: %s", test.recordType, test.name, err.Error())
+			continue
+		}
+		if strings.Join(answers, ",") != strings.Join(test.expected, ",") {
+			t.Errorf("%s %s: expected %v, got %v", test.recordType, test.name, test.expected, answers)
+		}
+	}
+
+	// Names are queried as given, without search domains
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	for _, name := range server.queries {
+		if name != "." && !strings.HasSuffix(name, ".test.") {
+			t.Errorf("unexpected query for %s", name)
+		}
+	}
+}
+
+func TestDNSQueryTruncated(t *testing.T) {
+	server := startTestDNSServer(t, testDNSRecords())
+	server.truncate.Store(true)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	answers, err := DNSQuery(ctx, server.address(), "A", "example.test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(answers, ",") != "192.0.2.1,192.0.2.2" {
+		t.Errorf("expected the answers via TCP, got %v", answers)
+	}
+}
+
+func TestDNSQueryTimeout(t *testing.T) {
+	// A socket that never answers
+	silent, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer silent.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	_, err = DNSQuery(ctx, silent.LocalAddr().String(), "A", "example.test")
+	if err == nil {
+		t.Error("expected a timeout")
+	}
+}
+
+func TestProbeDNS(t *testing.T) {
+	server := startTestDNSServer(t, testDNSRecords())
+
+	tests := []struct {
+		url     string
+		options DNSOptions
+		status  Status
+	}{
+		{"/example.test", DNSOptions{}, STATUS_GREEN},
+		{"/example.test", DNSOptions{Expect: StringList{"192.0.2.2", "192.0.2.1"}}, STATUS_GREEN},
+		{"/example.test", DNSOptions{Expect: StringList{"192.0.2.1"}}, STATUS_RED},
+		{"/example.test?type=MX", DNSOptions{Contains: StringList{"10 MAIL.example.test"}}, STATUS_GREEN},
+		{"/example.test", DNSOptions{Type: "MX", Contains: StringList{"30 mail.example.test"}}, STATUS_RED},
+		{"/example.test", DNSOptions{MinCount: 3}, STATUS_RED},
+		{"/example.test?type=CNAME", DNSOptions{MinCount: 1}, STATUS_RED},
+		{"/www.example.test?type=CNAME", DNSOptions{Expect: StringList{"example.test"}}, STATUS_GREEN},
+		{"/missing.test", DNSOptions{}, STATUS_RED},
+		{"/", DNSOptions{}, STATUS_RED},
+	}
+
+	for _, test := range tests {
+		endpoint := &Endpoint{Name: "dns", URL: "dns://" + server.address() + test.url, DNS: test.options}
+		if err := endpoint.DNS.prepare(); err != nil {
+			t.Fatal(err)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		result := probeDNS(ctx, &Group{Name: "DNS"}, endpoint)
+		cancel()
+
+		if result.Status != test.status {
+			t.Errorf("%s %+v: expected %s, got %s (%v, %s)", test.url, test.options, test.status, result.Status, result.FailedAssertions, result.Body)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type HTTPProber struct {
+	client *http.Client
+}
+
+func init() {
+	prober := NewHTTPProber()
+	RegisterProber("http", prober)
+	RegisterProber("https", prober)
+}
+
+func NewHTTPProber() *HTTPProber {
+	return &HTTPProber{
+		client: newProbeHTTPClient(&tls.Config{}),
+	}
+}
+
+func newProbeHTTPClient(tlsConfig *tls.Config) *http.Client {
+	return &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return errors.New("redirects are not allowed")
+		},
+		Transport: &http.Transport{
+			TLSClientConfig:     tlsConfig,
+			DisableCompression:  true,
+			DisableKeepAlives:   true,
+			MaxIdleConnsPerHost: 1,
+		},
+	}
+}
+
+// clientFor returns the HTTP client for the endpoint. As connections are not kept alive anyway,
+// endpoints with their own TLS settings get a new client for every request.
+func (p *HTTPProber) clientFor(endpoint *Endpoint) *http.Client {
+	if endpoint.TLS.isDefault() {
+		return p.client
+	}
+	return newProbeHTTPClient(endpoint.TLS.config())
+}
+
+func (p *HTTPProber) Probe(ctx context.Context, group *Group, endpoint *Endpoint) *Result {
+	uri, err := EndpointURL(group, endpoint)
+	if err != nil {
+		return errorResult(err)
+	}
+
+	result := &Result{
+		Status: STATUS_GREEN,
+	}
+
+	if len(endpoint.Query) > 0 {
+		query := uri.Query()
+		for name, value := range endpoint.Query {
+			query.Set(name, value)
+		}
+		uri.RawQuery = query.Encode()
+	}
+
+	var body io.Reader
+	if endpoint.Body != "" {
+		body = strings.NewReader(endpoint.Body)
+	}
+
+	startTime := time.Now()
+
+	// The method has been validated and defaulted when reading the configuration
+	request, err := http.NewRequestWithContext(ctx, endpoint.Method, uri.String(), body)
+	var response *http.Response
+	if err == nil {
+		for name, value := range endpoint.Headers {
+			if strings.EqualFold(name, "Host") {
+				request.Host = value
+			} else {
+				request.Header.Set(name, value)
+			}
+		}
+		if endpoint.Auth != nil {
+			err = endpoint.Auth.apply(ctx, request)
+		}
+	}
+	if err == nil {
+		response, err = p.clientFor(endpoint).Do(request)
+	}
+	if response != nil {
+		defer response.Body.Close()
+	}
+
+	result.RequestDuration = time.Since(startTime).Seconds()
+
+	if err != nil {
+		result.Body = []byte(err.Error())
+		result.Code = 999
+		result.Status = STATUS_RED
+		return result
+	}
+
+	result.Code = response.StatusCode
+
+	if endpoint.Method == http.MethodHead {
+		// For HEAD requests, we do not have a body to compare
+		result.Body = nil
+	} else {
+		body, err := io.ReadAll(response.Body)
+		if err != nil {
+			result.Body = []byte(err.Error())
+			result.Code = 998
+			result.Status = STATUS_RED
+			return result
+		}
+		result.ContentType = response.Header.Get("Content-Type")
+		result.Body = body
+	}
+
+	codeMatches := false
+	if endpoint.TargetStatus.Code == 0 {
+		// Check for Code in 200 range
+		codeMatches = response.StatusCode >= 200 && response.StatusCode < 300
+	} else {
+		// Check for exact code
+		codeMatches = response.StatusCode == endpoint.TargetStatus.Code
+	}
+	if !codeMatches {
+		if endpoint.Warning.hasCode(response.StatusCode) {
+			result.Status = STATUS_YELLOW
+		} else {
+			result.Status = STATUS_RED
+		}
+		result.FailedAssertions = append(result.FailedAssertions, fmt.Sprintf("unexpected response code %d", response.StatusCode))
+	}
+
+	if result.Status != STATUS_RED && endpoint.Method != http.MethodHead {
+		status, failures := endpoint.TargetStatus.checkBody(result.Body)
+		result.Status = worseStatus(result.Status, status)
+		result.FailedAssertions = append(result.FailedAssertions, failures...)
+	}
+
+	return result
+}
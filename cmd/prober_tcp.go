@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"net"
+	"time"
+)
+
+func init() {
+	RegisterProber("tcp", ProberFunc(probeTCP))
+}
+
+// probeTCP only opens a connection on the specified port and closes it directly
+func probeTCP(ctx context.Context, group *Group, endpoint *Endpoint) *Result {
+	uri, err := EndpointURL(group, endpoint)
+	if err != nil {
+		return errorResult(err)
+	}
+
+	hostname := uri.Hostname()
+	port := uri.Port()
+	if port == "" {
+		port = "80"
+	}
+
+	startTime := time.Now()
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(hostname, port))
+	if err != nil {
+		return errorResult(err)
+	}
+	conn.Close()
+
+	return &Result{
+		Status:          STATUS_GREEN,
+		RequestDuration: time.Since(startTime).Seconds(),
+	}
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"math"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	tlsDefaultWarningDays  = 30
+	tlsDefaultCriticalDays = 7
+)
+
+// TLSOptions configure the certificate verification for "https://" and "tls://" endpoints
+type TLSOptions struct {
+	InsecureSkipVerify bool    `yaml:"insecureSkipVerify,omitempty" json:"insecureSkipVerify,omitempty"`
+	CAFile             string  `yaml:"caFile,omitempty" json:"-"`
+	ServerName         string  `yaml:"serverName,omitempty" json:"serverName,omitempty"`
+	WarningDays        float64 `yaml:"warningDays,omitempty" json:"warningDays,omitempty"`
+	CriticalDays       float64 `yaml:"criticalDays,omitempty" json:"criticalDays,omitempty"`
+	rootCAs            *x509.CertPool
+}
+
+func init() {
+	RegisterProber("tls", ProberFunc(probeTLS))
+}
+
+// prepare sets defaults and reads the CA bundle. Relative paths are resolved against configDir.
+func (o *TLSOptions) prepare(configDir string) error {
+	if o.WarningDays <= 0 {
+		o.WarningDays = tlsDefaultWarningDays
+	}
+	if o.CriticalDays <= 0 {
+		o.CriticalDays = tlsDefaultCriticalDays
+	}
+
+	if o.CAFile != "" {
+		caFile := o.CAFile
+		if !filepath.IsAbs(caFile) {
+			caFile = filepath.Join(configDir, caFile)
+		}
+		data, err := os.ReadFile(caFile)
+		if err != nil {
+			return fmt.Errorf("tls.caFile: %s", err.Error())
+		}
+		o.rootCAs = x509.NewCertPool()
+		if !o.rootCAs.AppendCertsFromPEM(data) {
+			return fmt.Errorf("tls.caFile: no certificates found in %s", caFile)
+		}
+	}
+
+	return nil
+}
+
+// isDefault returns whether the options change nothing compared to the default verification
+func (o *TLSOptions) isDefault() bool {
+	return o == nil || (!o.InsecureSkipVerify && o.rootCAs == nil && o.ServerName == "")
+}
+
+func (o *TLSOptions) config() *tls.Config {
+	if o == nil {
+		return &tls.Config{}
+	}
+	return &tls.Config{
+		InsecureSkipVerify: o.InsecureSkipVerify,
+		RootCAs:            o.rootCAs,
+		ServerName:         o.ServerName,
+	}
+}
+
+// probeTLS connects to the endpoint, verifies the certificate chain and hostname and reports the
+// expiry of the certificate
+func probeTLS(ctx context.Context, group *Group, endpoint *Endpoint) *Result {
+	uri, err := EndpointURL(group, endpoint)
+	if err != nil {
+		return errorResult(err)
+	}
+
+	options := endpoint.TLS
+	if options == nil {
+		options = &TLSOptions{}
+		options.prepare("")
+	}
+
+	port := uri.Port()
+	if port == "" {
+		port = "443"
+	}
+	serverName := options.ServerName
+	if serverName == "" {
+		serverName = uri.Hostname()
+	}
+
+	startTime := time.Now()
+
+	// The verification is done below to be able to report the certificate even if it is not valid
+	dialer := &tls.Dialer{
+		Config: &tls.Config{
+			InsecureSkipVerify: true,
+			ServerName:         serverName,
+		},
+	}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(uri.Hostname(), port))
+	if err != nil {
+		return errorResult(err)
+	}
+	defer conn.Close()
+
+	result := &Result{
+		Status:          STATUS_GREEN,
+		ContentType:     "text/plain",
+		RequestDuration: time.Since(startTime).Seconds(),
+	}
+
+	certificates := conn.(*tls.Conn).ConnectionState().PeerCertificates
+	if len(certificates) == 0 {
+		return errorResult(fmt.Errorf("no certificate received"))
+	}
+	leaf := certificates[0]
+
+	if !options.InsecureSkipVerify {
+		intermediates := x509.NewCertPool()
+		for _, certificate := range certificates[1:] {
+			intermediates.AddCert(certificate)
+		}
+		_, err = leaf.Verify(x509.VerifyOptions{
+			DNSName:       serverName,
+			Roots:         options.rootCAs,
+			Intermediates: intermediates,
+		})
+		if err != nil {
+			result.Status = STATUS_RED
+			result.FailedAssertions = append(result.FailedAssertions, err.Error())
+		}
+	}
+
+	days := time.Until(leaf.NotAfter).Hours() / 24
+	if days < options.CriticalDays {
+		result.Status = STATUS_RED
+		result.FailedAssertions = append(result.FailedAssertions, fmt.Sprintf("certificate expires in less than %g days", options.CriticalDays))
+	} else if days < options.WarningDays {
+		result.Status = worseStatus(result.Status, STATUS_YELLOW)
+		result.FailedAssertions = append(result.FailedAssertions, fmt.Sprintf("certificate expires in less than %g days", options.WarningDays))
+	}
+
+	sans := make([]string, 0, len(leaf.DNSNames)+len(leaf.IPAddresses))
+	sans = append(sans, leaf.DNSNames...)
+	for _, ip := range leaf.IPAddresses {
+		sans = append(sans, ip.String())
+	}
+
+	result.Body = []byte(fmt.Sprintf("Subject: %s\nIssuer: %s\nSANs: %s\nValid from: %s\nValid until: %s\nDays until expiry: %d\n",
+		leaf.Subject.String(),
+		leaf.Issuer.String(),
+		strings.Join(sans, ", "),
+		leaf.NotBefore.Format(time.RFC3339),
+		leaf.NotAfter.Format(time.RFC3339),
+		int(math.Floor(days)),
+	))
+
+	return result
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// RequestOptions customize the HTTP requests of endpoints. Set on a group, they are the defaults
+// for all endpoints of the group.
+type RequestOptions struct {
+	Method   string                `yaml:"method,omitempty" json:"method,omitempty"`
+	Headers  map[string]string     `yaml:"headers,omitempty" json:"-"`
+	Query    map[string]string     `yaml:"query,omitempty" json:"-"`
+	Body     string                `yaml:"body,omitempty" json:"-"`
+	BodyFile string                `yaml:"bodyFile,omitempty" json:"-"`
+	Auth     *TargetAuthentication `yaml:"auth,omitempty" json:"-"`
+	TLS      *TLSOptions           `yaml:"tls,omitempty" json:"tls,omitempty"`
+}
+
+// inherit fills all options that are not set from the given defaults. Headers and query parameters
+// are merged.
+func (o *RequestOptions) inherit(defaults RequestOptions) {
+	if o.Method == "" {
+		o.Method = defaults.Method
+	}
+	if o.Body == "" && o.BodyFile == "" {
+		o.Body = defaults.Body
+		o.BodyFile = defaults.BodyFile
+	}
+	if o.Auth == nil && defaults.Auth != nil {
+		auth := *defaults.Auth
+		o.Auth = &auth
+	}
+	if o.TLS == nil && defaults.TLS != nil {
+		options := *defaults.TLS
+		o.TLS = &options
+	}
+	o.Headers = mergeStringMaps(defaults.Headers, o.Headers)
+	o.Query = mergeStringMaps(defaults.Query, o.Query)
+}
+
+// prepare validates the method (falling back to the default method) and reads the body file and
+// secrets. Relative paths are resolved against the directory of the configuration file.
+func (o *RequestOptions) prepare(defaultMethod string, configDir string) error {
+	o.Method = strings.ToUpper(o.Method)
+	if !isSupportedHttpMethod(o.Method) {
+		if o.Method != "" {
+			fmt.Fprintf(os.Stderr, "Error: HTTP Method %s not supported. Defaulting to %s\n", o.Method, defaultMethod)
+		}
+		o.Method = defaultMethod
+	}
+
+	if o.BodyFile != "" {
+		bodyFile := o.BodyFile
+		if !filepath.IsAbs(bodyFile) {
+			bodyFile = filepath.Join(configDir, bodyFile)
+		}
+		data, err := os.ReadFile(bodyFile)
+		if err != nil {
+			return fmt.Errorf("cannot read bodyFile: %s", err.Error())
+		}
+		o.Body = string(data)
+	}
+
+	if o.Auth != nil {
+		err := o.Auth.prepare(configDir)
+		if err != nil {
+			return err
+		}
+	}
+
+	if o.TLS != nil {
+		err := o.TLS.prepare(configDir)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func isSupportedHttpMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodDelete, http.MethodPatch, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+func mergeStringMaps(defaults map[string]string, values map[string]string) map[string]string {
+	if len(defaults) == 0 {
+		return values
+	}
+
+	merged := make(map[string]string, len(defaults)+len(values))
+	for k, v := range defaults {
+		merged[k] = v
+	}
+	for k, v := range values {
+		merged[k] = v
+	}
+	return merged
+}
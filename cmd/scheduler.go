@@ -0,0 +1,230 @@
+package main
+
+import (
+	"container/heap"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+const (
+	schedulerMinInterval = 1
+	schedulerJitter      = 0.1 // Maximum jitter as fraction of the interval
+	schedulerMaxWait     = 2 * time.Second
+	schedulerRampStep    = time.Second // Time between the first checks of each worker after a start
+)
+
+// prepareSchedule sets interval and timeout of the endpoint from the group or the global refresh
+// interval if they are not set. The timeout defaults to the interval.
+func (e *Endpoint) prepareSchedule(group *Group, refreshInterval float64) error {
+	if e.Interval < 0 || e.Timeout < 0 || group.Interval < 0 || group.Timeout < 0 {
+		return fmt.Errorf("interval and timeout must not be negative")
+	}
+
+	if e.Interval == 0 {
+		e.Interval = group.Interval
+	}
+	if e.Interval == 0 {
+		e.Interval = refreshInterval
+	}
+	if e.Interval < schedulerMinInterval {
+		out("Configuration: Interval of endpoint %s too low: %f. Set to %d\n", e.Name, e.Interval, schedulerMinInterval)
+		e.Interval = schedulerMinInterval
+	}
+
+	if e.Timeout == 0 {
+		e.Timeout = group.Timeout
+	}
+	if e.Timeout == 0 {
+		e.Timeout = e.Interval
+	}
+	return nil
+}
+
+func (e *Endpoint) interval() time.Duration {
+	return time.Duration(e.Interval * float64(time.Second))
+}
+
+func (e *Endpoint) timeout() time.Duration {
+	return time.Duration(e.Timeout * float64(time.Second))
+}
+
+// scheduledCheck is the next planned check of an endpoint
+type scheduledCheck struct {
+	group      *Group
+	endpoint   *Endpoint
+	due        time.Time
+	offset     time.Duration // Added once to the next check, so that the checks are spread across the interval
+	generation int64
+	index      int
+}
+
+// checkQueue implements heap.Interface, ordered by the due time of the checks
+type checkQueue []*scheduledCheck
+
+func (q checkQueue) Len() int           { return len(q) }
+func (q checkQueue) Less(i, j int) bool { return q[i].due.Before(q[j].due) }
+
+func (q checkQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *checkQueue) Push(x any) {
+	check := x.(*scheduledCheck)
+	check.index = len(*q)
+	*q = append(*q, check)
+}
+
+func (q *checkQueue) Pop() any {
+	old := *q
+	n := len(old)
+	check := old[n-1]
+	old[n-1] = nil
+	check.index = -1
+	*q = old[:n-1]
+	return check
+}
+
+// Scheduler plans the checks of all endpoints according to their intervals. Each endpoint is in
+// the queue at most once; a check is only scheduled again after it has been run.
+type Scheduler struct {
+	mutex      sync.Mutex
+	queue      checkQueue
+	generation int64
+	wake       chan bool
+	lag        time.Duration
+}
+
+func NewScheduler() *Scheduler {
+	return &Scheduler{
+		queue: make(checkQueue, 0),
+		wake:  make(chan bool, 1),
+	}
+}
+
+// SetConfiguration replaces all planned checks with the endpoints of the configuration. Endpoints
+// that have been checked within their interval (according to lastCheck) are due one interval after
+// that check. All others are spread evenly across their interval, so that they are not checked at
+// the same moment. Their first check runs earlier, with about one check per worker and second, so
+// that all endpoints have a result soon after a start; the check after it is delayed accordingly.
+// Checks of the previous configuration that are still running are not scheduled again.
+func (s *Scheduler) SetConfiguration(config *Configuration, lastCheck func(endpoint *Endpoint) time.Time) {
+	now := time.Now()
+	checks := make([]*scheduledCheck, 0)
+	overdue := make([]*scheduledCheck, 0)
+	for _, group := range config.AllGroups() {
+		for _, endpoint := range group.Endpoints {
+			check := &scheduledCheck{
+				group:    group,
+				endpoint: endpoint,
+				due:      lastCheck(endpoint).Add(endpoint.interval()),
+			}
+			if check.due.Before(now) {
+				overdue = append(overdue, check)
+			} else {
+				check.due = check.due.Add(jitter(endpoint.interval()))
+				checks = append(checks, check)
+			}
+		}
+	}
+	workers := config.Workers
+	if workers <= 0 {
+		workers = workersDefault
+	}
+	ramp := schedulerRampStep * time.Duration((len(overdue)+workers-1)/workers)
+	for i, check := range overdue {
+		spread := check.endpoint.interval() * time.Duration(i) / time.Duration(len(overdue))
+		first := ramp * time.Duration(i) / time.Duration(len(overdue))
+		if first > spread {
+			first = spread
+		}
+		check.due = now.Add(first)
+		check.offset = spread - first
+		checks = append(checks, check)
+	}
+
+	s.mutex.Lock()
+	s.generation++
+	s.queue = make(checkQueue, 0, len(checks))
+	for _, check := range checks {
+		check.generation = s.generation
+		heap.Push(&s.queue, check)
+	}
+	s.mutex.Unlock()
+
+	s.notify()
+}
+
+// Next waits until the earliest check is due and removes it from the queue. It returns nil if no
+// check became due within the maximum wait time, so callers can check whether they should stop.
+func (s *Scheduler) Next() *scheduledCheck {
+	s.mutex.Lock()
+	wait := schedulerMaxWait
+	if len(s.queue) > 0 {
+		next := s.queue[0]
+		untilDue := time.Until(next.due)
+		if untilDue <= 0 {
+			heap.Pop(&s.queue)
+			s.lag = -untilDue
+			s.mutex.Unlock()
+			return next
+		}
+		if untilDue < wait {
+			wait = untilDue
+		}
+	}
+	s.mutex.Unlock()
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-s.wake:
+	}
+	return nil
+}
+
+// Reschedule plans the next check of the endpoint one interval (plus jitter and the offset after
+// the first check) from now
+func (s *Scheduler) Reschedule(check *scheduledCheck) {
+	s.mutex.Lock()
+	if check.generation != s.generation {
+		s.mutex.Unlock()
+		return
+	}
+	interval := check.endpoint.interval()
+	check.due = time.Now().Add(interval + check.offset).Add(jitter(interval))
+	check.offset = 0
+	heap.Push(&s.queue, check)
+	s.mutex.Unlock()
+
+	s.notify()
+}
+
+// Lag returns how late the last check was started compared to its due time
+func (s *Scheduler) Lag() time.Duration {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.lag
+}
+
+// notify wakes up a waiting call of Next, so that it considers changed due times
+func (s *Scheduler) notify() {
+	select {
+	case s.wake <- true:
+	default:
+	}
+}
+
+// jitter returns a random duration of up to 10% of the interval, so that endpoints with the same
+// interval are not all checked at the same moment
+func jitter(interval time.Duration) time.Duration {
+	max := int64(float64(interval) * schedulerJitter)
+	if max <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(max))
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"container/heap"
+	"strconv"
+	"testing"
+	"time"
+)
+
+const schedulerTestConfiguration = `
+title: Test
+refreshInterval: 60
+workers: 2
+authorization:
+  type: none
+groups:
+  - name: Shops
+    url: http://example.com/
+    endpoints:
+      - name: A
+        url: /a
+      - name: B
+        url: /b
+      - name: C
+        url: /c
+      - name: D
+        url: /d
+        interval: 1
+      - name: Recent
+        url: /recent
+`
+
+func TestSchedulerSetConfiguration(t *testing.T) {
+	config, err := readTestConfiguration(t, schedulerTestConfiguration)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	scheduler := NewScheduler()
+	scheduler.SetConfiguration(config, func(endpoint *Endpoint) time.Time {
+		if endpoint.Name == "Recent" {
+			return now.Add(-30 * time.Second)
+		}
+		return time.Time{}
+	})
+
+	// 4 overdue checks with 2 workers: the first round within 2 seconds (but D within its interval
+	// of 1 second), the second round one interval later, spread across the interval
+	tests := []struct {
+		name   string
+		first  time.Duration
+		spread time.Duration
+	}{
+		{"A", 0, 0},
+		{"B", 500 * time.Millisecond, 15 * time.Second},
+		{"D", 750 * time.Millisecond, 750 * time.Millisecond},
+		{"C", 1 * time.Second, 30 * time.Second},
+		{"Recent", 30 * time.Second, -1},
+	}
+
+	const tolerance = 100 * time.Millisecond
+	checks := make([]*scheduledCheck, 0)
+	for scheduler.queue.Len() > 0 {
+		checks = append(checks, heap.Pop(&scheduler.queue).(*scheduledCheck))
+	}
+	if len(checks) != len(tests) {
+		t.Fatalf("expected every endpoint once, got %d checks", len(checks))
+	}
+
+	for i, test := range tests {
+		check := checks[i]
+		if check.endpoint.Name != test.name {
+			t.Fatalf("expected %s, got %s", test.name, check.endpoint.Name)
+		}
+		if test.spread < 0 {
+			// Checked within its interval: due one interval (plus jitter) after the last check
+			if check.due.Before(now.Add(test.first)) || check.due.After(now.Add(test.first+6*time.Second)) {
+				t.Errorf("%s: expected due in %s plus jitter, got %s", test.name, test.first, check.due.Sub(now))
+			}
+			continue
+		}
+		if delta := check.due.Sub(now) - test.first; delta < -tolerance || delta > tolerance {
+			t.Errorf("%s: expected the first check in %s, got %s", test.name, test.first, check.due.Sub(now))
+		}
+
+		// Rescheduled right away instead of after the first check
+		scheduler.Reschedule(check)
+		heap.Remove(&scheduler.queue, check.index)
+		interval := check.endpoint.interval()
+		expected := now.Add(interval + test.spread - test.first)
+		if check.due.Before(expected.Add(-tolerance)) || check.due.After(expected.Add(interval/10+tolerance)) {
+			t.Errorf("%s: expected the second check in %s plus jitter, got %s", test.name, expected.Sub(now), check.due.Sub(now))
+		}
+
+		// The offset only applies once
+		scheduler.Reschedule(check)
+		heap.Remove(&scheduler.queue, check.index)
+		expected = time.Now().Add(interval)
+		if check.due.Before(expected.Add(-tolerance)) || check.due.After(expected.Add(interval/10+tolerance)) {
+			t.Errorf("%s: expected the third check in %s plus jitter, got %s", test.name, interval, check.due.Sub(now))
+		}
+	}
+}
+
+func TestJitter(t *testing.T) {
+	tests := []struct {
+		interval time.Duration
+		max      time.Duration
+	}{
+		{0, 0},
+		{5 * time.Nanosecond, 0},
+		{time.Second, 100 * time.Millisecond},
+		{time.Hour, 6 * time.Minute},
+	}
+
+	for _, test := range tests {
+		for i := 0; i < 100; i++ {
+			value := jitter(test.interval)
+			if value < 0 || value > test.max || (test.max > 0 && value == test.max) {
+				t.Errorf("%s: expected jitter below %s, got %s", test.interval, test.max, value)
+				break
+			}
+		}
+	}
+}
+
+func TestCheckQueue(t *testing.T) {
+	now := time.Now()
+	queue := make(checkQueue, 0)
+	for _, seconds := range []int{30, 10, 50, 0, 20, 40} {
+		heap.Push(&queue, &scheduledCheck{
+			endpoint: &Endpoint{Name: strconv.Itoa(seconds)},
+			due:      now.Add(time.Duration(seconds) * time.Second),
+		})
+	}
+
+	// Removing a check keeps the order of the others
+	for _, check := range queue {
+		if check.endpoint.Name == "20" {
+			heap.Remove(&queue, check.index)
+			break
+		}
+	}
+
+	expected := []string{"0", "10", "30", "40", "50"}
+	for _, name := range expected {
+		check := heap.Pop(&queue).(*scheduledCheck)
+		if check.endpoint.Name != name {
+			t.Errorf("expected %s, got %s", name, check.endpoint.Name)
+		}
+		if check.index != -1 {
+			t.Errorf("%s: expected no index after removal, got %d", name, check.index)
+		}
+	}
+	if queue.Len() != 0 {
+		t.Errorf("expected an empty queue, %d left", queue.Len())
+	}
+}
+
+func TestSchedulerNext(t *testing.T) {
+	config, err := readTestConfiguration(t, schedulerTestConfiguration)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// All endpoints were checked recently, "Recent" is due first
+	scheduler := NewScheduler()
+	scheduler.SetConfiguration(config, func(endpoint *Endpoint) time.Time {
+		if endpoint.Name == "Recent" {
+			return time.Now().Add(-2 * time.Minute)
+		}
+		return time.Now()
+	})
+
+	check := scheduler.Next()
+	if check == nil || check.endpoint.Name != "Recent" {
+		t.Fatalf("expected the overdue check, got %v", check)
+	}
+	if lag := scheduler.Lag(); lag < 0 || lag > 100*time.Millisecond {
+		t.Errorf("expected no lag for a check that was spread, got %s", lag)
+	}
+
+	// Nothing else is due: Next returns after being woken up
+	scheduler.notify()
+	if check := scheduler.Next(); check != nil {
+		t.Errorf("expected no due check, got %s", check.endpoint.Name)
+	}
+
+	// Checks of the previous configuration are not planned again
+	scheduler.SetConfiguration(config, func(endpoint *Endpoint) time.Time { return time.Now() })
+	length := scheduler.queue.Len()
+	scheduler.Reschedule(check)
+	if scheduler.queue.Len() != length {
+		t.Errorf("expected the check of the previous configuration to be dropped")
+	}
+}
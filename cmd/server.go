@@ -1,21 +1,17 @@
 package main
 
 import (
-	"bytes"
 	"context"
-	"crypto/tls"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"io"
 	"io/fs"
-	"net"
 	"net/http"
 	"net/url"
 	"os"
-	"os/exec"
-	"strings"
+	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -23,19 +19,32 @@ type Server struct {
 	Active           bool
 	port             uint
 	fs               fs.ReadFileFS
-	configuration    *Configuration
+	configuration    atomic.Pointer[Configuration]
+	configVersion    atomic.Int64
 	webserver        *http.Server
 	results          map[string]*Result
 	resultsMutex     sync.Mutex
 	resultsChanged   bool
+	groupStatus      map[string]Status
+	groupStable      map[string]Status // Group status before contributing endpoints started flapping
 	resultsCacheFile *os.File
+	history          *History
+	state            *State
+	notifier         *Notifier
+	events           *EventHub
+	scheduler        *Scheduler
+	workers          *WorkerPool
 	startTime        time.Time
 	lastUpdate       time.Time
-	httpClient       *http.Client
 	updateInProgress bool
+
+	// Internal metrics
+	probesInFlight        atomic.Int64
+	lastUpdateAllDuration atomic.Int64 // Nanoseconds
+	lastCacheWrite        atomic.Int64 // Unix nanoseconds
 }
 
-func NewServer(port uint, fs fs.ReadFileFS, cacheFile string, config *Configuration) *Server {
+func NewServer(port uint, fs fs.ReadFileFS, cacheFile string, historyFile string, stateFile string, config *Configuration) *Server {
 	var err error
 	var resultsCacheFile *os.File
 	if cacheFile == "" {
@@ -47,34 +56,112 @@ func NewServer(port uint, fs fs.ReadFileFS, cacheFile string, config *Configurat
 		outFatal(EXIT_CACHE_FILE, "Could not open cache file: %s\n", err.Error())
 	}
 
-	httpClient := &http.Client{
-		Timeout: time.Duration(config.RefreshInterval) * time.Second,
-		CheckRedirect: func(req *http.Request, via []*http.Request) error {
-			return errors.New("redirects are not allowed")
-		},
-		Transport: &http.Transport{
-			TLSClientConfig: &tls.Config{
-				InsecureSkipVerify: true,
-			},
-			DisableCompression:  true,
-			DisableKeepAlives:   true,
-			MaxIdleConnsPerHost: 1,
-		},
+	var history *History
+	if historyFile != "" {
+		history, err = NewHistory(historyFile, config.History)
+		if err != nil {
+			outFatal(EXIT_HISTORY_FILE, "Could not open history file: %s\n", err.Error())
+		}
+	}
+
+	state, err := NewState(stateFile)
+	if err != nil {
+		outFatal(EXIT_STATE_FILE, "Could not read state file: %s\n", err.Error())
 	}
 
-	return &Server{
+	s := &Server{
 		Active:           true,
 		port:             port,
 		fs:               fs,
-		configuration:    config,
 		resultsCacheFile: resultsCacheFile,
-		httpClient:       httpClient,
+		history:          history,
+		state:            state,
+		notifier:         NewNotifier(config.Notifications),
+		events:           NewEventHub(),
+		scheduler:        NewScheduler(),
+		workers:          NewWorkerPool(config.Workers, config.MaxChecksPerHost),
+		groupStatus:      make(map[string]Status),
+		groupStable:      make(map[string]Status),
 	}
+	s.configuration.Store(config)
+	s.configVersion.Store(time.Now().UnixNano())
+
+	s.migrateStateKeys(config)
+
+	// Heartbeat endpoints are evaluated with the pushes stored in the state
+	heartbeatProber.SetState(state)
+	return s
+}
+
+// config returns the currently active configuration. It is replaced as a whole on reload, so
+// callers should keep the returned pointer instead of calling config() repeatedly.
+func (s *Server) config() *Configuration {
+	return s.configuration.Load()
 }
 
+// setConfiguration activates a new (already validated) configuration. Results of endpoints that
+// still exist are kept, all others are dropped.
 func (s *Server) setConfiguration(config *Configuration) {
-	s.configuration = config
-	s.updateAllGroups()
+	keep := make(map[string]bool)
+	keepGroups := make(map[string]bool)
+	for _, group := range config.AllGroups() {
+		keepGroups[group.Name] = true
+		for _, endpoint := range group.Endpoints {
+			keep[endpoint.ID] = true
+		}
+	}
+
+	version := time.Now().UnixNano()
+
+	s.resultsMutex.Lock()
+	s.configuration.Store(config)
+	s.configVersion.Store(version)
+	for key := range s.results {
+		if !keep[key] {
+			delete(s.results, key)
+			s.resultsChanged = true
+		}
+	}
+	for name := range s.groupStatus {
+		if !keepGroups[name] {
+			delete(s.groupStatus, name)
+			delete(s.groupStable, name)
+		}
+	}
+	s.resultsMutex.Unlock()
+
+	// IDs might have been set or changed
+	s.migrateStateKeys(config)
+	if s.history != nil {
+		s.history.SetConfiguration(config.History)
+	}
+	s.notifier.SetConfiguration(config.Notifications)
+	s.workers.SetLimits(config.Workers, config.MaxChecksPerHost)
+
+	s.events.Publish(Event{
+		Type: EVENT_TYPE_CONFIG,
+		Data: ConfigEvent{Version: version},
+	})
+
+	// The aggregation of groups might have changed. Subgroups are updated before their parents.
+	groups := config.AllGroups()
+	for i := len(groups) - 1; i >= 0; i-- {
+		s.updateGroupStatus(groups[i])
+	}
+
+	s.scheduler.SetConfiguration(config, s.lastCheck)
+}
+
+// ReloadConfiguration reads the configuration file and activates it if it is valid
+func (s *Server) ReloadConfiguration(configPath string) error {
+	config, err := ReadConfiguration(configPath)
+	if err != nil {
+		return err
+	}
+
+	s.setConfiguration(config)
+	s.logHeartbeatURLs()
+	return nil
 }
 
 func (s *Server) Run() error {
@@ -84,7 +171,9 @@ func (s *Server) Run() error {
 	webHandler := &http.ServeMux{}
 	webHandler.HandleFunc("/", s.handleRootRequest)
 	webHandler.HandleFunc("/api/", s.handleAPIRequest)
+	webHandler.HandleFunc("/api/push/", s.handlePushRequest)
 	webHandler.HandleFunc("/status/", s.handleStatusRequest)
+	webHandler.HandleFunc("/metrics", s.handleMetricsRequest)
 
 	// Serve web application
 	s.webserver = &http.Server{
@@ -95,8 +184,9 @@ func (s *Server) Run() error {
 		Handler:      webHandler,
 		// Errorlog:     logger.log.Getlogger(logger.logLevelError),
 	}
+	s.webserver.RegisterOnShutdown(s.events.Close)
 
-	s.results = make(map[string]*Result, len(s.configuration.Groups))
+	s.results = make(map[string]*Result, len(s.config().Groups))
 	if s.resultsCacheFile != nil {
 		data, err := io.ReadAll(s.resultsCacheFile)
 		if err != nil {
@@ -107,19 +197,35 @@ func (s *Server) Run() error {
 			if err != nil {
 				outError("Could not parse cache file %s: %s\n", s.resultsCacheFile.Name(), err.Error())
 				outError("Starting without cache")
-				s.results = make(map[string]*Result, len(s.configuration.Groups))
+				s.results = make(map[string]*Result, len(s.config().Groups))
+			} else if migrateResultKeys(s.config(), s.results) {
+				// Older cache files store the results by URL
+				s.resultsChanged = true
 			}
 		}
 	}
 
+	// Subgroups are computed before their parents
+	s.resultsMutex.Lock()
+	groups := s.config().AllGroups()
+	for i := len(groups) - 1; i >= 0; i-- {
+		s.groupStatus[groups[i].Name] = s.computeGroupStatus(groups[i])
+	}
+	s.resultsMutex.Unlock()
+
 	// Allow graceful shutdown
 	go s.checkForShutdown()
 
+	s.logHeartbeatURLs()
+
 	// Keep Group data up to date
-	go s.checkUpdateGroups()
+	s.scheduler.SetConfiguration(s.config(), s.lastCheck)
+	go s.checkScheduledEndpoints()
 
 	go s.checkResultsUpdate()
 
+	go s.checkHistoryCompaction()
+
 	out("Listening on port %d\n", s.port)
 	return s.webserver.ListenAndServe()
 }
@@ -153,6 +259,8 @@ func (s *Server) checkResultsUpdate() {
 				_, err = s.resultsCacheFile.Seek(0, 0)
 				if err != nil {
 					outError("Cannot save results to cache: Error resetting cache file position")
+				} else {
+					s.lastCacheWrite.Store(time.Now().UnixNano())
 				}
 			}
 
@@ -162,6 +270,27 @@ func (s *Server) checkResultsUpdate() {
 	}
 }
 
+func (s *Server) checkHistoryCompaction() {
+	if s.history == nil {
+		return
+	}
+
+	var nextCompaction = time.Now()
+	for s.Active {
+		if nextCompaction.Before(time.Now()) {
+			err := s.history.Compact()
+			if err != nil {
+				outError("Cannot compact history: %s\n", err.Error())
+			} else {
+				outDebug("History compacted\n")
+			}
+			nextCompaction = time.Now().Add(time.Hour)
+		}
+
+		time.Sleep(2 * time.Second)
+	}
+}
+
 func (s *Server) checkForShutdown() {
 	for s.Active {
 		time.Sleep(2 * time.Second)
@@ -169,30 +298,56 @@ func (s *Server) checkForShutdown() {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 	s.webserver.Shutdown(ctx)
+	s.workers.Close()
+
+	if s.history != nil {
+		s.history.Close()
+	}
 }
 
-func (s *Server) checkUpdateGroups() {
-	var nextUpdate = time.Now()
+// checkScheduledEndpoints passes the checks planned by the scheduler to the worker pool
+func (s *Server) checkScheduledEndpoints() {
 	for s.Active {
-		if nextUpdate.Before(time.Now()) {
-			s.updateAllGroups()
-			nextUpdate = time.Now().Add(time.Duration(int64(s.configuration.RefreshInterval) * int64(time.Second)))
+		check := s.scheduler.Next()
+		if check == nil {
+			continue
 		}
 
-		time.Sleep(3 * time.Second)
+		s.workers.Submit(endpointHost(check.group, check.endpoint), func() {
+			s.updateEndpoint(check.group, check.endpoint)
+			s.scheduler.Reschedule(check)
+		})
 	}
 }
 
+// lastCheck returns the time of the last check of the endpoint or the zero time if there was none
+func (s *Server) lastCheck(endpoint *Endpoint) time.Time {
+	s.resultsMutex.Lock()
+	defer s.resultsMutex.Unlock()
+
+	result, ok := s.results[endpoint.ID]
+	if !ok {
+		return time.Time{}
+	}
+	return result.Updated
+}
+
 func (s *Server) updateAllGroups() {
+	startTime := time.Now()
+	defer func() {
+		s.lastUpdateAllDuration.Store(int64(time.Since(startTime)))
+	}()
+
 	allDone := make([]chan bool, 0, 100)
-	for _, group := range s.configuration.Groups {
+	for _, group := range s.config().AllGroups() {
 		for _, endpoint := range group.Endpoints {
+			group, endpoint := group, endpoint
 			done := make(chan bool, 1)
 			allDone = append(allDone, done)
-			go func(group *Group, endpoint *Endpoint, done chan bool) {
+			s.workers.Submit(endpointHost(group, endpoint), func() {
 				s.updateEndpoint(group, endpoint)
 				done <- true
-			}(group, endpoint, done)
+			})
 		}
 	}
 
@@ -205,13 +360,13 @@ func (s *Server) updateEndpoint(group *Group, endpoint *Endpoint) {
 	uri := s.getEndpointUrl(group, endpoint)
 
 	s.resultsMutex.Lock()
-	result, ok := s.results[uri.String()]
+	result, ok := s.results[endpoint.ID]
 	if !ok {
 		result = &Result{}
 	}
 	s.resultsMutex.Unlock()
 
-	if time.Since(result.Updated).Seconds() < s.configuration.RefreshInterval {
+	if time.Since(result.Updated) < endpoint.interval() {
 		return
 	}
 
@@ -221,151 +376,119 @@ func (s *Server) updateEndpoint(group *Group, endpoint *Endpoint) {
 		result.Status = STATUS_INACTIVE
 		result.Updated = time.Now()
 		s.resultsChanged = result.Status != STATUS_INACTIVE
-	} else if uri.Scheme == "tcp" {
-		// TCP target
-		hostname := uri.Hostname()
-		port := uri.Port()
-		if port == "" {
-			port = "80"
-		}
-
-		conn, err := net.Dial(uri.Scheme, net.JoinHostPort(hostname, port))
-		if err != nil {
-			result.Status = STATUS_RED
-			result.ContentType = "text/plain"
-			result.Body = []byte(err.Error())
-		} else {
-			result.Status = STATUS_GREEN
-			conn.Close()
-		}
+	} else if prober := ProberFor(uri.Scheme); prober != nil {
+		result = s.probeWithRetries(prober, group, endpoint)
 		result.Updated = time.Now()
 
-	} else if uri.Scheme == "ping" {
-		// Ping target
-		// TODO: Workaround. Replace with actually pinging via Go library
-		cmd := exec.Command("/bin/sh", "-c", "/bin/ping -c 1 -w 5 -q "+uri.Hostname())
-		out, err := cmd.CombinedOutput()
-
-		if err != nil {
-			result.Status = STATUS_RED
-			result.ContentType = "text/plain"
-			result.Body = []byte(err.Error() + "\n-----\n" + string(out))
-		} else {
-			fmt.Fprintf(os.Stderr, "Ping result for %s:\n\n%s\n\n", uri.Hostname(), string(out))
-
-			if err != nil {
-				result.Status = STATUS_RED
-				result.ContentType = "text/plain"
-				out = append([]byte(err.Error()), []byte("\n-----\n"+string(out))...)
-				result.Body = []byte(out)
-			} else if strings.Contains(string(out), "0 packets received") {
-				result.Status = STATUS_RED
-				result.ContentType = "text/plain"
-				result.Body = []byte(out)
-			} else {
-				result.Status = STATUS_GREEN
+		s.resultsMutex.Lock()
+		confirmStatus(endpoint, s.results[endpoint.ID], result)
+		if result.Status == STATUS_RED {
+			if chain := s.blockedBy(endpoint); chain != nil {
+				result.Status = STATUS_BLOCKED
+				result.BlockedBy = chain
 			}
 		}
-		result.Updated = time.Now()
+		// The checks are confirmed during maintenance as well, so that the end of the window is
+		// compared to the confirmed status
+		if window := s.maintenanceFor(group, endpoint, result.Updated); window != nil {
+			result.checkedStatus = result.Status
+			result.Status = STATUS_MAINTENANCE
+			result.Maintenance = window
+		}
+		detectFlapping(endpoint, s.results[endpoint.ID], result)
+		s.resultsMutex.Unlock()
 
-	} else if uri.Scheme == "https" || uri.Scheme == "http" {
-		// Do not refresh more than once per minute
+		if s.history != nil {
+			s.history.Record(endpoint.ID, result)
+		}
 
-		result.Status = STATUS_GREEN
+		outDebug("%s --> %s %d (%f) %s\n", uri.String(), result.Status, result.Code, result.RequestDuration, result.PendingStatus)
+		s.resultsChanged = true
+	} else {
+		outError("Invalid URL scheme for endpoint %s in group %s: %s", endpoint.Name, group.Name, uri.Scheme)
+		result.Status = STATUS_RED
+	}
 
-		var response *http.Response
-		var err error
+	s.resultsMutex.Lock()
+	previous := s.results[endpoint.ID]
+	s.results[endpoint.ID] = result
+	s.resultsMutex.Unlock()
 
-		startTime := time.Now()
+	s.events.Publish(Event{
+		Type: EVENT_TYPE_RESULT,
+		Data: NewResultEvent(endpoint.ID, result),
+	})
 
-		httpMethod := s.configuration.DefaultHttpMethod
-		if endpoint.Method != "" {
-			httpMethod = endpoint.Method
-		}
+	if result.Status == STATUS_GREEN {
+		s.clearAck(group.Name, endpoint.ID)
+	}
 
-		switch httpMethod {
-		case http.MethodHead:
-			response, err = s.httpClient.Head(uri.String())
-		case http.MethodGet:
-			response, err = s.httpClient.Get(uri.String())
-		default:
-			err = fmt.Errorf("unsupported HTTP method for client request: %s", httpMethod)
-		}
-		if response != nil {
-			defer response.Body.Close()
-		}
+	if previous != nil && isDown(previous.Status) != isDown(result.Status) {
+		s.recheckDependents(endpoint)
+	}
 
-		result.RequestDuration = time.Since(startTime).Seconds()
-		result.Updated = time.Now()
+	if oldStatus, notify := endpointTransition(previous, result); notify {
+		s.notifier.NotifyEndpoint(group, endpoint, oldStatus, result)
+	}
 
-		if err != nil {
-			result.Body = []byte(err.Error())
-			result.Code = 999
-			result.Status = STATUS_RED
-		} else {
-			result.Code = response.StatusCode
+	s.updateGroupStatus(group)
+}
 
-			body := []byte{}
-			if httpMethod == http.MethodHead {
-				// For HEAD requests, we do not have a body to compare
-				result.Status = STATUS_GREEN
-				result.Body = nil
-			} else {
-				body, err = io.ReadAll(response.Body)
-				if err != nil {
-					result.Body = []byte(err.Error())
-					result.Code = 998
-					result.Status = STATUS_RED
-				} else {
-					result.ContentType = response.Header.Get("Content-Type")
-					result.Body = body
-				}
-			}
+// updateGroupStatus computes the status of the group from its endpoint results and subgroups,
+// notifies about changes and updates the parent group
+func (s *Server) updateGroupStatus(group *Group) {
+	s.resultsMutex.Lock()
+	status := s.computeGroupStatus(group)
+	previous, ok := s.groupStatus[group.Name]
+	s.groupStatus[group.Name] = status
+	flapping := s.isGroupFlapping(group)
+	stable, wasFlapping := s.groupStable[group.Name]
+	if flapping && !wasFlapping && ok {
+		s.groupStable[group.Name] = previous
+	} else if !flapping && wasFlapping {
+		delete(s.groupStable, group.Name)
+	}
+	s.resultsMutex.Unlock()
 
-			if endpoint.TargetStatus.Code == 0 {
-				// Check for Code in 200 range
-				if response.StatusCode < 200 || response.StatusCode >= 300 {
-					result.Status = STATUS_RED
-				}
-			}
+	if previous != status || !ok {
+		s.events.Publish(Event{
+			Type: EVENT_TYPE_GROUP,
+			Data: GroupEvent{Name: group.Name, Status: status},
+		})
+	}
 
-			if result.Status == STATUS_GREEN && endpoint.TargetStatus.Code > 0 {
-				// Check for exact code
-				if response.StatusCode != endpoint.TargetStatus.Code {
-					result.Status = STATUS_RED
-				}
-			}
+	if status == STATUS_GREEN {
+		s.clearAck(group.Name, "")
+	}
 
-			if result.Status == STATUS_GREEN && len(endpoint.TargetStatus.Body) > 0 {
-				// Copare response body
-				if !bytes.Equal(body, endpoint.TargetStatus.Body) {
-					result.Status = STATUS_RED
-				}
-			}
+	if flapping {
+		// Transitions are not notified while endpoints are flapping, only the status when they stop
+	} else if wasFlapping {
+		if isTransition(stable, status) {
+			s.notifier.NotifyGroup(group, stable, status)
 		}
+	} else if ok && isTransition(previous, status) {
+		s.notifier.NotifyGroup(group, previous, status)
+	}
 
-		// TODO: Save result for statistical analysis
-
-		if endpoint.TargetStatus.Code != 0 && result.Code != endpoint.TargetStatus.Code {
-			// Check response status
-			result.Status = STATUS_RED
-		}
-		if len(endpoint.TargetStatus.Body) != 0 && !bytes.Equal(result.Body, endpoint.TargetStatus.Body) {
-			// Check response status
-			result.Status = STATUS_RED
-		}
-		result.Updated = time.Now()
+	if group.parent != nil {
+		s.updateGroupStatus(group.parent)
+	}
+}
 
-		outDebug("GET %s%s --> %d (%f)\n", group.URL, endpoint.URL, result.Code, result.RequestDuration)
-		s.resultsChanged = true
-	} else {
-		outError("Invalid URL scheme for endpoint %s in group %s: %s", endpoint.Name, group.Name, uri.Scheme)
-		result.Status = STATUS_RED
+// computeGroupStatus returns the status of the group. Must be called with resultsMutex locked.
+func (s *Server) computeGroupStatus(group *Group) Status {
+	if group.ForcedStatus != "" {
+		return group.ForcedStatus
+	}
+	if group.Inactive {
+		return STATUS_INACTIVE
+	}
+	if s.groupInMaintenance(group, time.Now()) {
+		return STATUS_MAINTENANCE
 	}
 
-	s.resultsMutex.Lock()
-	defer s.resultsMutex.Unlock()
-	s.results[endpoint.URL] = result
+	return s.aggregate(group)
 }
 
 func (s *Server) respond(w http.ResponseWriter, r *http.Request, response any) {
@@ -379,15 +502,34 @@ func (s *Server) respond(w http.ResponseWriter, r *http.Request, response any) {
 }
 
 func (s *Server) respondConfig() any {
-	return s.configuration
+	return s.config()
+}
+
+// ReadAllResponse contains the results of all endpoints and the status of all groups
+type ReadAllResponse struct {
+	Endpoints map[string]*Result `json:"endpoints"`
+	Groups    map[string]Status  `json:"groups"`
 }
 
 func (s *Server) respondReadAll() any {
-	return s.results
+	s.resultsMutex.Lock()
+	defer s.resultsMutex.Unlock()
+
+	response := ReadAllResponse{
+		Endpoints: make(map[string]*Result, len(s.results)),
+		Groups:    make(map[string]Status, len(s.groupStatus)),
+	}
+	for key, result := range s.results {
+		response.Endpoints[key] = result
+	}
+	for name, status := range s.groupStatus {
+		response.Groups[name] = status
+	}
+	return response
 }
 
 func (s *Server) groupByName(groupName string) *Group {
-	for _, l := range s.configuration.Groups {
+	for _, l := range s.config().AllGroups() {
 		if l.Name == groupName {
 			return l
 		}
@@ -396,6 +538,9 @@ func (s *Server) groupByName(groupName string) *Group {
 }
 
 func (s *Server) endpointByName(group *Group, endpointName string) *Endpoint {
+	if group == nil {
+		return nil
+	}
 	for _, e := range group.Endpoints {
 		if e.Name == endpointName {
 			return e
@@ -405,28 +550,26 @@ func (s *Server) endpointByName(group *Group, endpointName string) *Endpoint {
 }
 
 func (s *Server) getEndpointUrl(group *Group, endpoint *Endpoint) *url.URL {
-	baseUri, err := url.Parse(group.URL)
+	uri, err := EndpointURL(group, endpoint)
 	if err != nil {
-		outError("Invalid base URL for group %s: %s. Error: %s", group.Name, group.URL, err.Error())
+		outError("%s\n", err.Error())
 		return nil
 	}
-
-	uri, err := baseUri.Parse(endpoint.URL)
-	if err != nil {
-		outError("Invalid URL for endpoint %s in group %s: %s. Error: %s", endpoint.Name, group.Name, endpoint.URL, err.Error())
-		return nil
-	}
-
 	return uri
 }
 
 func (s *Server) respondRead(groupName string, endpointName string) any {
-	group := s.groupByName(groupName)
-	endpoint := s.endpointByName(group, endpointName)
-
-	uri := s.getEndpointUrl(group, endpoint)
+	endpoint := s.endpointByName(s.groupByName(groupName), endpointName)
+	if endpoint == nil {
+		return Error{
+			Code:    400,
+			Message: "Invalid group/endpoint selection",
+		}
+	}
 
-	res, ok := s.results[uri.String()]
+	s.resultsMutex.Lock()
+	res, ok := s.results[endpoint.ID]
+	s.resultsMutex.Unlock()
 	if !ok {
 		return Error{
 			Code:    400,
@@ -436,6 +579,76 @@ func (s *Server) respondRead(groupName string, endpointName string) any {
 	return res
 }
 
+type HistoryResponse struct {
+	Group    string              `json:"group"`
+	Endpoint string              `json:"endpoint"`
+	From     time.Time           `json:"from"`
+	To       time.Time           `json:"to"`
+	Entries  []HistoryEntry      `json:"entries"`
+	Uptime   map[string]*float64 `json:"uptime"`
+}
+
+func (s *Server) respondHistory(groupName string, endpointName string, fromParam string, toParam string) any {
+	if s.history == nil {
+		return Error{
+			Code:    404,
+			Message: "History is not enabled",
+		}
+	}
+
+	var endpoint *Endpoint
+	if group := s.groupByName(groupName); group != nil {
+		endpoint = s.endpointByName(group, endpointName)
+	}
+	if endpoint == nil {
+		return Error{
+			Code:    400,
+			Message: "Invalid group/endpoint selection",
+		}
+	}
+
+	now := time.Now()
+	from, err := parseTimeParam(fromParam, now.Add(-24*time.Hour))
+	if err != nil {
+		return Error{
+			Code:    400,
+			Message: "Invalid value for from: " + err.Error(),
+		}
+	}
+	to, err := parseTimeParam(toParam, now)
+	if err != nil {
+		return Error{
+			Code:    400,
+			Message: "Invalid value for to: " + err.Error(),
+		}
+	}
+
+	return HistoryResponse{
+		Group:    groupName,
+		Endpoint: endpointName,
+		From:     from,
+		To:       to,
+		Entries:  s.history.Entries(endpoint.ID, from, to),
+		Uptime: map[string]*float64{
+			"24h": s.history.Uptime(endpoint.ID, now.Add(-24*time.Hour)),
+			"7d":  s.history.Uptime(endpoint.ID, now.Add(-7*24*time.Hour)),
+			"30d": s.history.Uptime(endpoint.ID, now.Add(-30*24*time.Hour)),
+		},
+	}
+}
+
+// parseTimeParam parses RFC 3339 timestamps or unix timestamps in seconds
+func parseTimeParam(value string, defaultTime time.Time) (time.Time, error) {
+	if value == "" {
+		return defaultTime, nil
+	}
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err == nil {
+		return time.Unix(seconds, 0), nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
 func (s *Server) respondRefresh(groupName string, endpointName string) any {
 	group := s.groupByName(groupName)
 	endpoint := s.endpointByName(group, endpointName)
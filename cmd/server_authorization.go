@@ -10,7 +10,7 @@ import (
 )
 
 func (s *Server) authorized(r *http.Request) *Error {
-	switch s.configuration.Authorization.Type {
+	switch s.config().Authorization.Type {
 	case "none":
 		return nil
 
@@ -29,27 +29,29 @@ func (s *Server) authorized(r *http.Request) *Error {
 }
 
 func (s *Server) authorizeClientCert(r *http.Request) *Error {
+	authorization := s.config().Authorization
+
 	// TODO: Parse root certs when reading configuration, not on every request
-	if s.configuration.Authorization.Cert == "" {
+	if authorization.Cert == "" {
 		return &Error{
 			Code:    500,
 			Message: "Root certificate not configured",
 		}
 	}
 
-	cert, serverErr := ParseCertificateBase64(r.Header.Get(s.configuration.Authorization.Header))
+	cert, serverErr := ParseCertificateBase64(r.Header.Get(authorization.Header))
 	if serverErr != nil {
 		return serverErr
 	}
 
-	serverErr = VerifyCertificate(cert, s.configuration.Authorization.Cert)
+	serverErr = VerifyCertificate(cert, authorization.Cert)
 	if serverErr != nil {
 		return serverErr
 	}
 
 	// Verify against allowlist if there are entries
-	if len(s.configuration.Authorization.Users) > 0 {
-		if !s.configuration.Authorization.authorizedUsers[cert.Subject.CommonName] {
+	if len(authorization.Users) > 0 {
+		if !authorization.authorizedUsers[cert.Subject.CommonName] {
 			return &Error{
 				Code:    403,
 				Message: "User not authorized",
@@ -69,7 +71,9 @@ func (s *Server) authorizeClientCertInfo(r *http.Request) *Error {
 	// 	}
 	// }
 
-	sdn := strings.ToLower(r.Header.Get(s.configuration.Authorization.Header))
+	authorization := s.config().Authorization
+
+	sdn := strings.ToLower(r.Header.Get(authorization.Header))
 	if sdn == "" {
 		return &Error{
 			Code:    401,
@@ -77,17 +81,8 @@ func (s *Server) authorizeClientCertInfo(r *http.Request) *Error {
 		}
 	}
 
-	var user string
-	sndParts := strings.Split(sdn, ",")
-	for _, part := range sndParts {
-		entry := strings.Split(strings.TrimSpace(part), "=")
-		if len(entry) == 2 && entry[0] == "cn" {
-			user = entry[1]
-			break
-		}
-	}
-
-	if !s.configuration.Authorization.authorizedUsers[user] {
+	user := commonName(sdn)
+	if !authorization.authorizedUsers[user] {
 		return &Error{
 			Code:    403,
 			Message: "User not authorized",
@@ -97,8 +92,39 @@ func (s *Server) authorizeClientCertInfo(r *http.Request) *Error {
 	return nil
 }
 
+// requestUser returns the common name of the client certificate of the (already authorized)
+// request or an empty string if the authorization type does not identify users
+func (s *Server) requestUser(r *http.Request) string {
+	authorization := s.config().Authorization
+
+	switch authorization.Type {
+	case AUTH_TYPE_CERT:
+		cert, err := ParseCertificateBase64(r.Header.Get(authorization.Header))
+		if err != nil {
+			return ""
+		}
+		return cert.Subject.CommonName
+
+	case AUTH_TYPE_CERT_INFO:
+		return commonName(strings.ToLower(r.Header.Get(authorization.Header)))
+	}
+	return ""
+}
+
 /// Functions
 
+// commonName returns the CN of a (lower case) subject distinguished name
+func commonName(sdn string) string {
+	sndParts := strings.Split(sdn, ",")
+	for _, part := range sndParts {
+		entry := strings.Split(strings.TrimSpace(part), "=")
+		if len(entry) == 2 && entry[0] == "cn" {
+			return entry[1]
+		}
+	}
+	return ""
+}
+
 func ParseCertificateBase64(certStringBase64 string) (*x509.Certificate, *Error) {
 	certData, err := base64.StdEncoding.DecodeString(certStringBase64)
 	if err != nil {
@@ -5,12 +5,14 @@ import (
 	"fmt"
 	"mime"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 )
 
 func (s *Server) handleAPIRequest(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Config-Version", strconv.FormatInt(s.configVersion.Load(), 10))
 
 	err := s.authorized(r)
 	if err != nil {
@@ -26,15 +28,31 @@ func (s *Server) handleAPIRequest(w http.ResponseWriter, r *http.Request) {
 			s.respond(w, r, s.respondConfig())
 
 		case "read":
-			s.respond(w, r, s.respondRead(r.URL.Query().Get("group"), r.URL.Query().Get("endpoint")))
+			s.respond(w, r, s.respondRead(s.endpointSelection(r)))
 
 		case "refresh":
-			s.respond(w, r, s.respondRefresh(r.URL.Query().Get("group"), r.URL.Query().Get("endpoint")))
+			s.respond(w, r, s.respondRefresh(s.endpointSelection(r)))
 
 		case "refreshAll":
 			s.updateAllGroups()
 			s.respond(w, r, s.respondReadAll())
 
+		case "history":
+			query := r.URL.Query()
+			s.respond(w, r, s.respondHistory(query.Get("group"), query.Get("endpoint"), query.Get("from"), query.Get("to")))
+
+		case "ack":
+			s.handleAck(w, r)
+
+		case "maintenance":
+			s.handleMaintenance(w, r)
+
+		case "heartbeat":
+			s.handleHeartbeat(w, r)
+
+		case "events":
+			s.handleEvents(w, r)
+
 		case "readAll":
 			s.respond(w, r, s.respondReadAll())
 
@@ -113,3 +131,17 @@ func (s *Server) handleRootRequest(w http.ResponseWriter, r *http.Request) {
 	w.Write(content)
 
 }
+
+// endpointSelection returns the group and endpoint name from the query parameters group and
+// endpoint or from the endpoint ID in the query parameter id
+func (s *Server) endpointSelection(r *http.Request) (string, string) {
+	query := r.URL.Query()
+	if id := query.Get("id"); id != "" {
+		group, endpoint := s.endpointByID(id)
+		if endpoint == nil {
+			return "", ""
+		}
+		return group.Name, endpoint.Name
+	}
+	return query.Get("group"), query.Get("endpoint")
+}
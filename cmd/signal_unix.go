@@ -0,0 +1,11 @@
+//go:build !windows
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// Signals that trigger reloading the configuration file
+var reloadSignals = []os.Signal{syscall.SIGHUP, syscall.SIGUSR1}
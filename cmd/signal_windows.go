@@ -0,0 +1,9 @@
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// Signals that trigger reloading the configuration file
+var reloadSignals = []os.Signal{syscall.SIGHUP}
@@ -0,0 +1,276 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// State contains the data that is created at runtime (e.g. via the API) and has to survive restarts. It
+// is written to the state file on every change. Without state file, it is only kept in memory.
+type State struct {
+	mutex sync.Mutex
+	file  string
+
+	Maintenance []*MaintenanceWindow       `json:"maintenance"`
+	Acks        []*Acknowledgement         `json:"acknowledgements"`
+	Heartbeats  map[string]*HeartbeatState `json:"heartbeats"`
+}
+
+func NewState(stateFile string) (*State, error) {
+	st := &State{
+		file:        stateFile,
+		Maintenance: make([]*MaintenanceWindow, 0),
+		Acks:        make([]*Acknowledgement, 0),
+		Heartbeats:  make(map[string]*HeartbeatState),
+	}
+	if stateFile == "" {
+		return st, nil
+	}
+
+	data, err := os.ReadFile(stateFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return st, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(data, st)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse state file %s: %s", stateFile, err.Error())
+	}
+
+	windows := st.Maintenance
+	st.Maintenance = make([]*MaintenanceWindow, 0, len(windows))
+	for _, window := range windows {
+		err = window.prepare()
+		if err != nil {
+			outError("Ignoring invalid maintenance window %s in state file: %s\n", window.ID, err.Error())
+			continue
+		}
+		st.Maintenance = append(st.Maintenance, window)
+	}
+
+	if st.Acks == nil {
+		st.Acks = make([]*Acknowledgement, 0)
+	}
+	if st.Heartbeats == nil {
+		st.Heartbeats = make(map[string]*HeartbeatState)
+	}
+
+	return st, nil
+}
+
+// MaintenanceWindows returns all maintenance windows created via the API. The slice is replaced
+// on changes and must not be modified.
+func (st *State) MaintenanceWindows() []*MaintenanceWindow {
+	st.mutex.Lock()
+	defer st.mutex.Unlock()
+	return st.Maintenance
+}
+
+// AddMaintenance adds the window and removes expired ones
+func (st *State) AddMaintenance(window *MaintenanceWindow) error {
+	st.mutex.Lock()
+	defer st.mutex.Unlock()
+
+	now := time.Now()
+	windows := make([]*MaintenanceWindow, 0, len(st.Maintenance)+1)
+	for _, w := range st.Maintenance {
+		if !w.Expired(now) {
+			windows = append(windows, w)
+		}
+	}
+	st.Maintenance = append(windows, window)
+	return st.save()
+}
+
+// RemoveMaintenance removes the window with the given id and returns it or nil if it does not exist
+func (st *State) RemoveMaintenance(id string) (*MaintenanceWindow, error) {
+	st.mutex.Lock()
+	defer st.mutex.Unlock()
+
+	for i, window := range st.Maintenance {
+		if window.ID == id {
+			windows := make([]*MaintenanceWindow, 0, len(st.Maintenance)-1)
+			windows = append(windows, st.Maintenance[:i]...)
+			st.Maintenance = append(windows, st.Maintenance[i+1:]...)
+			return window, st.save()
+		}
+	}
+	return nil, nil
+}
+
+// Acknowledgements returns all acknowledgements. The slice is replaced on changes and must not be
+// modified.
+func (st *State) Acknowledgements() []*Acknowledgement {
+	st.mutex.Lock()
+	defer st.mutex.Unlock()
+	return st.Acks
+}
+
+// Acknowledged returns whether there is an acknowledgement for the endpoint with the given ID or
+// the group (if id is empty)
+func (st *State) Acknowledged(groupName string, id string) bool {
+	st.mutex.Lock()
+	defer st.mutex.Unlock()
+
+	for _, ack := range st.Acks {
+		if ack.matches(groupName, id) {
+			return true
+		}
+	}
+	return false
+}
+
+// SetAcknowledgement adds the acknowledgement or replaces an existing one for the same endpoint or group
+func (st *State) SetAcknowledgement(ack *Acknowledgement) error {
+	st.mutex.Lock()
+	defer st.mutex.Unlock()
+
+	acks := make([]*Acknowledgement, 0, len(st.Acks)+1)
+	for _, a := range st.Acks {
+		if !a.matches(ack.Group, ack.ID) {
+			acks = append(acks, a)
+		}
+	}
+	st.Acks = append(acks, ack)
+	return st.save()
+}
+
+// RemoveAcknowledgement removes the acknowledgement for the endpoint with the given ID or the group
+// (if id is empty) and returns it or nil if it does not exist
+func (st *State) RemoveAcknowledgement(groupName string, id string) (*Acknowledgement, error) {
+	st.mutex.Lock()
+	defer st.mutex.Unlock()
+
+	for i, ack := range st.Acks {
+		if ack.matches(groupName, id) {
+			acks := make([]*Acknowledgement, 0, len(st.Acks)-1)
+			acks = append(acks, st.Acks[:i]...)
+			st.Acks = append(acks, st.Acks[i+1:]...)
+			return ack, st.save()
+		}
+	}
+	return nil, nil
+}
+
+// Heartbeat returns (a copy of) the state of the heartbeat endpoint. If there is none yet, a new
+// token is created.
+func (st *State) Heartbeat(key string) HeartbeatState {
+	st.mutex.Lock()
+	defer st.mutex.Unlock()
+
+	heartbeat, ok := st.Heartbeats[key]
+	if !ok {
+		heartbeat = &HeartbeatState{
+			Token:   newHeartbeatToken(),
+			Created: time.Now(),
+		}
+		st.Heartbeats[key] = heartbeat
+		err := st.save()
+		if err != nil {
+			outError("Cannot save heartbeat token: %s\n", err.Error())
+		}
+	}
+	return *heartbeat
+}
+
+// RecordPush stores a push of the heartbeat endpoint
+func (st *State) RecordPush(key string, status Status, message string, t time.Time) error {
+	st.mutex.Lock()
+	defer st.mutex.Unlock()
+
+	heartbeat, ok := st.Heartbeats[key]
+	if !ok {
+		heartbeat = &HeartbeatState{
+			Token:   newHeartbeatToken(),
+			Created: t,
+		}
+		st.Heartbeats[key] = heartbeat
+	}
+	heartbeat.LastPush = t
+	heartbeat.Status = status
+	heartbeat.Message = message
+	return st.save()
+}
+
+// MigrateKeys adapts the state to the endpoint IDs of the configuration. Heartbeats that are
+// stored by group and endpoint name (as in older state files or before an ID was set) are moved to
+// the ID of the endpoint. Endpoint acknowledgements get the ID of their endpoint and its current
+// names; the ones of unknown endpoints without ID are dropped.
+func (st *State) MigrateKeys(config *Configuration) error {
+	st.mutex.Lock()
+	defer st.mutex.Unlock()
+
+	changed := migrateKeys(config, st.Heartbeats, func(existing *HeartbeatState, moved *HeartbeatState) *HeartbeatState {
+		return existing
+	})
+
+	names := endpointIDsByName(config)
+	endpoints := make(map[string]*Acknowledgement, len(names))
+	for _, group := range config.AllGroups() {
+		for _, endpoint := range group.Endpoints {
+			endpoints[endpoint.ID] = &Acknowledgement{Group: group.Name, Endpoint: endpoint.Name}
+		}
+	}
+	acks := make([]*Acknowledgement, 0, len(st.Acks))
+	for _, ack := range st.Acks {
+		id := ack.ID
+		if ack.Endpoint != "" && id == "" {
+			var ok bool
+			if id, ok = names[ack.Group+"/"+ack.Endpoint]; !ok {
+				changed = true
+				continue
+			}
+		}
+		// Acknowledgements are not modified, since they might be in use
+		if current, ok := endpoints[id]; ok && (id != ack.ID || ack.Group != current.Group || ack.Endpoint != current.Endpoint) {
+			migrated := *ack
+			migrated.ID, migrated.Group, migrated.Endpoint = id, current.Group, current.Endpoint
+			ack = &migrated
+			changed = true
+		}
+		acks = append(acks, ack)
+	}
+	st.Acks = acks
+
+	if !changed {
+		return nil
+	}
+	return st.save()
+}
+
+// save writes the state file. Must be called with the mutex locked.
+func (st *State) save() error {
+	if st.file == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmpFile, err := os.CreateTemp(filepath.Dir(st.file), filepath.Base(st.file)+".*")
+	if err != nil {
+		return err
+	}
+	_, err = tmpFile.Write(data)
+	if err == nil {
+		err = tmpFile.Close()
+	}
+	if err == nil {
+		err = os.Rename(tmpFile.Name(), st.file)
+	}
+	if err != nil {
+		tmpFile.Close()
+		os.Remove(tmpFile.Name())
+		return fmt.Errorf("cannot write state file %s: %s", st.file, err.Error())
+	}
+	return nil
+}
@@ -3,30 +3,68 @@ package main
 import (
 	"io/fs"
 	"os"
+	"regexp"
 	"strings"
 	"time"
 )
 
 type Group struct {
-	Inactive     bool        `yaml:"inactive" json:"inactive"`
-	Name         string      `yaml:"name" json:"name"`
-	Category     string      `yaml:"category,omitempty" json:"category,omitempty"`
-	URL          string      `yaml:"url" json:"url"`
-	Endpoints    []*Endpoint `yaml:"endpoints,omitempty" json:"endpoints,omitempty"`
-	ForcedStatus Status      `yaml:"forced_status,omitempty" json:"forced_status,omitempty"`
+	Inactive         bool                     `yaml:"inactive" json:"inactive"`
+	Name             string                   `yaml:"name" json:"name"`
+	Category         string                   `yaml:"category,omitempty" json:"category,omitempty"`
+	URL              string                   `yaml:"url" json:"url"`
+	Endpoints        []*Endpoint              `yaml:"endpoints,omitempty" json:"endpoints,omitempty"`
+	Groups           []*Group                 `yaml:"groups,omitempty" json:"groups,omitempty"`
+	Weight           float64                  `yaml:"weight,omitempty" json:"weight,omitempty"`
+	Critical         bool                     `yaml:"critical,omitempty" json:"critical,omitempty"`
+	ForcedStatus     Status                   `yaml:"forced_status,omitempty" json:"forced_status,omitempty"`
+	Interval         float64                  `yaml:"interval,omitempty" json:"interval,omitempty"`
+	Timeout          float64                  `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+	Retries          int                      `yaml:"retries,omitempty" json:"retries,omitempty"`
+	RetryDelay       float64                  `yaml:"retryDelay,omitempty" json:"retryDelay,omitempty"`
+	FailureThreshold int                      `yaml:"failureThreshold,omitempty" json:"failureThreshold,omitempty"`
+	SuccessThreshold int                      `yaml:"successThreshold,omitempty" json:"successThreshold,omitempty"`
+	Flapping         FlappingConfiguration    `yaml:"flapping,omitempty" json:"flapping,omitempty"`
+	Maintenance      []*MaintenanceWindow     `yaml:"maintenance,omitempty" json:"maintenance,omitempty"`
+	DependsOn        StringList               `yaml:"dependsOn,omitempty" json:"dependsOn,omitempty"`
+	Aggregation      AggregationConfiguration `yaml:"aggregation,omitempty" json:"aggregation,omitempty"`
+	RequestOptions   `yaml:",inline"`
+	parent           *Group
 }
 
 type Endpoint struct {
-	Inactive     bool         `yaml:"inactive" json:"inactive"`
-	Name         string       `yaml:"name" json:"name"`
-	URL          string       `yaml:"url" json:"url"`
-	Method       string       `yaml:"method" json:"method"`
-	TargetStatus TargetStatus `yaml:"targetStatus" json:"targetStatus"`
+	Inactive         bool                  `yaml:"inactive" json:"inactive"`
+	ID               string                `yaml:"id,omitempty" json:"id"`
+	Name             string                `yaml:"name" json:"name"`
+	URL              string                `yaml:"url" json:"url"`
+	Interval         float64               `yaml:"interval,omitempty" json:"interval,omitempty"`
+	Timeout          float64               `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+	Retries          int                   `yaml:"retries,omitempty" json:"retries,omitempty"`
+	RetryDelay       float64               `yaml:"retryDelay,omitempty" json:"retryDelay,omitempty"`
+	FailureThreshold int                   `yaml:"failureThreshold,omitempty" json:"failureThreshold,omitempty"`
+	SuccessThreshold int                   `yaml:"successThreshold,omitempty" json:"successThreshold,omitempty"`
+	Flapping         FlappingConfiguration `yaml:"flapping,omitempty" json:"flapping,omitempty"`
+	Maintenance      []*MaintenanceWindow  `yaml:"maintenance,omitempty" json:"maintenance,omitempty"`
+	DependsOn        StringList            `yaml:"dependsOn,omitempty" json:"dependsOn,omitempty"`
+	Weight           float64               `yaml:"weight,omitempty" json:"weight,omitempty"`
+	Critical         bool                  `yaml:"critical,omitempty" json:"critical,omitempty"`
+	RequestOptions   `yaml:",inline"`
+	TargetStatus     TargetStatus      `yaml:"targetStatus" json:"targetStatus"`
+	Warning          WarningStatus     `yaml:"warning,omitempty" json:"warning,omitempty"`
+	Ping             PingConfiguration `yaml:"ping,omitempty" json:"ping,omitempty"`
+	DNS              DNSOptions        `yaml:"dns,omitempty" json:"dns,omitempty"`
+	Heartbeat        HeartbeatOptions  `yaml:"heartbeat,omitempty" json:"heartbeat,omitempty"`
+	dependencies     []endpointRef
 }
 
 type TargetStatus struct {
-	Code int    `yaml:"code,omitempty" json:"code,omitempty"`
-	Body []byte `yaml:"body,omitempty" json:"body,omitempty"`
+	Code        int              `yaml:"code,omitempty" json:"code,omitempty"`
+	Body        []byte           `yaml:"body,omitempty" json:"body,omitempty"`
+	Contains    StringList       `yaml:"contains,omitempty" json:"contains,omitempty"`
+	NotContains StringList       `yaml:"notContains,omitempty" json:"notContains,omitempty"`
+	Regex       StringList       `yaml:"regex,omitempty" json:"regex,omitempty"`
+	JSON        []*JSONAssertion `yaml:"json,omitempty" json:"json,omitempty"`
+	regex       []*regexp.Regexp
 }
 
 type Error struct {
@@ -42,6 +80,20 @@ type Result struct {
 	Body            []byte    `json:"body"`
 	RequestDuration float64   `json:"request_duration"`
 	Updated         time.Time `json:"updated"`
+
+	FailedAssertions []string `json:"failed_assertions,omitempty"`
+
+	PendingStatus Status `json:"pending_status,omitempty"` // Observed status that is not confirmed yet
+	PendingCount  int    `json:"pending_count,omitempty"`  // Number of consecutive checks that disagreed with the status
+
+	Flapping     bool `json:"flapping,omitempty"`
+	recentStatus []Status
+	stableStatus Status // Last notified status while flapping
+
+	Maintenance   *MaintenanceWindow `json:"maintenance,omitempty"`
+	checkedStatus Status             // Confirmed status behind the maintenance
+
+	BlockedBy []string `json:"blocked_by,omitempty"` // Dependency chain that causes the failure, the last one is red
 }
 
 type FrontendFS struct {
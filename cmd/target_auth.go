@@ -0,0 +1,227 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// Tokens are refreshed when they expire within this time
+	oauth2TokenRefreshMargin = 30 * time.Second
+	oauth2DefaultExpiry      = 5 * time.Minute
+)
+
+// Secret is read from an environment variable or a file, so that it does not have to be written
+// into the configuration file
+type Secret struct {
+	Env  string `yaml:"env,omitempty"`
+	File string `yaml:"file,omitempty"`
+}
+
+func (s Secret) isSet() bool {
+	return s.Env != "" || s.File != ""
+}
+
+// read returns the secret. Files are resolved against configDir, trailing line breaks are removed.
+func (s Secret) read(configDir string) (string, error) {
+	if s.Env != "" {
+		value, ok := os.LookupEnv(s.Env)
+		if !ok {
+			return "", fmt.Errorf("environment variable %s is not set", s.Env)
+		}
+		return value, nil
+	}
+
+	if s.File != "" {
+		file := s.File
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(configDir, file)
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	}
+
+	return "", fmt.Errorf("either env or file must be set")
+}
+
+// TargetAuthentication describes how requests to a monitored endpoint are authenticated
+type TargetAuthentication struct {
+	Type         string   `yaml:"type"`
+	Username     string   `yaml:"username,omitempty"`
+	Password     Secret   `yaml:"password,omitempty"`
+	Token        Secret   `yaml:"token,omitempty"`
+	TokenURL     string   `yaml:"tokenUrl,omitempty"`
+	ClientID     string   `yaml:"clientId,omitempty"`
+	ClientSecret Secret   `yaml:"clientSecret,omitempty"`
+	Scopes       []string `yaml:"scopes,omitempty"`
+	password     string
+	token        string
+	clientSecret string
+}
+
+// prepare validates the configuration and reads the secrets
+func (a *TargetAuthentication) prepare(configDir string) error {
+	var err error
+
+	switch a.Type {
+	case TARGET_AUTH_BASIC:
+		if a.Username == "" {
+			return fmt.Errorf("auth.username must be set for type %s", a.Type)
+		}
+		if a.Password.isSet() {
+			a.password, err = a.Password.read(configDir)
+			if err != nil {
+				return fmt.Errorf("auth.password: %s", err.Error())
+			}
+		}
+
+	case TARGET_AUTH_BEARER:
+		a.token, err = a.Token.read(configDir)
+		if err != nil {
+			return fmt.Errorf("auth.token: %s", err.Error())
+		}
+
+	case TARGET_AUTH_OAUTH2:
+		if a.TokenURL == "" || a.ClientID == "" {
+			return fmt.Errorf("auth.tokenUrl and auth.clientId must be set for type %s", a.Type)
+		}
+		a.clientSecret, err = a.ClientSecret.read(configDir)
+		if err != nil {
+			return fmt.Errorf("auth.clientSecret: %s", err.Error())
+		}
+
+	default:
+		return fmt.Errorf("auth.type must be one of \"%s\", \"%s\" or \"%s\"", TARGET_AUTH_BASIC, TARGET_AUTH_BEARER, TARGET_AUTH_OAUTH2)
+	}
+
+	return nil
+}
+
+// apply adds the credentials to the request
+func (a *TargetAuthentication) apply(ctx context.Context, request *http.Request) error {
+	switch a.Type {
+	case TARGET_AUTH_BASIC:
+		request.SetBasicAuth(a.Username, a.password)
+
+	case TARGET_AUTH_BEARER:
+		request.Header.Set("Authorization", "Bearer "+a.token)
+
+	case TARGET_AUTH_OAUTH2:
+		token, err := oauth2Tokens.get(ctx, a)
+		if err != nil {
+			return fmt.Errorf("cannot get OAuth2 token: %s", err.Error())
+		}
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	return nil
+}
+
+type oauth2Token struct {
+	mutex       sync.Mutex
+	accessToken string
+	expires     time.Time
+}
+
+// oauth2TokenCache keeps client credentials tokens until shortly before they expire
+type oauth2TokenCache struct {
+	mutex  sync.Mutex
+	tokens map[string]*oauth2Token
+	client *http.Client
+}
+
+var oauth2Tokens = &oauth2TokenCache{
+	tokens: make(map[string]*oauth2Token),
+	client: &http.Client{},
+}
+
+func (c *oauth2TokenCache) get(ctx context.Context, auth *TargetAuthentication) (string, error) {
+	key := strings.Join([]string{auth.TokenURL, auth.ClientID, auth.clientSecret, strings.Join(auth.Scopes, " ")}, "\n")
+
+	c.mutex.Lock()
+	token, ok := c.tokens[key]
+	if !ok {
+		token = &oauth2Token{}
+		c.tokens[key] = token
+	}
+	c.mutex.Unlock()
+
+	// Only one request per token fetches a new one, the others wait for it
+	token.mutex.Lock()
+	defer token.mutex.Unlock()
+
+	if token.accessToken != "" && time.Until(token.expires) > oauth2TokenRefreshMargin {
+		return token.accessToken, nil
+	}
+
+	accessToken, expiresIn, err := c.fetch(ctx, auth)
+	if err != nil {
+		return "", err
+	}
+	token.accessToken = accessToken
+	token.expires = time.Now().Add(expiresIn)
+	outDebug("Fetched OAuth2 token for client %s from %s (expires in %s)\n", auth.ClientID, auth.TokenURL, expiresIn)
+
+	return token.accessToken, nil
+}
+
+// fetch requests a new token via the client credentials grant
+func (c *oauth2TokenCache) fetch(ctx context.Context, auth *TargetAuthentication) (string, time.Duration, error) {
+	form := url.Values{}
+	form.Set("grant_type", "client_credentials")
+	if len(auth.Scopes) > 0 {
+		form.Set("scope", strings.Join(auth.Scopes, " "))
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, auth.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", 0, err
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Accept", "application/json")
+	request.SetBasicAuth(url.QueryEscape(auth.ClientID), url.QueryEscape(auth.clientSecret))
+
+	response, err := c.client.Do(request)
+	if err != nil {
+		return "", 0, err
+	}
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return "", 0, err
+	}
+	if response.StatusCode != http.StatusOK {
+		return "", 0, fmt.Errorf("token endpoint returned %s", response.Status)
+	}
+
+	tokenResponse := struct {
+		AccessToken string  `json:"access_token"`
+		ExpiresIn   float64 `json:"expires_in"`
+	}{}
+	err = json.Unmarshal(body, &tokenResponse)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid token response: %s", err.Error())
+	}
+	if tokenResponse.AccessToken == "" {
+		return "", 0, fmt.Errorf("token response does not contain an access_token")
+	}
+
+	expiresIn := oauth2DefaultExpiry
+	if tokenResponse.ExpiresIn > 0 {
+		expiresIn = time.Duration(tokenResponse.ExpiresIn * float64(time.Second))
+	}
+	return tokenResponse.AccessToken, expiresIn, nil
+}
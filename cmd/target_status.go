@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+)
+
+// StringList can be given as a single string or as a list of strings in the configuration
+type StringList []string
+
+func (l *StringList) UnmarshalYAML(unmarshal func(any) error) error {
+	var single string
+	if unmarshal(&single) == nil {
+		*l = StringList{single}
+		return nil
+	}
+
+	var list []string
+	err := unmarshal(&list)
+	if err != nil {
+		return err
+	}
+	*l = list
+	return nil
+}
+
+// prepare validates the target status and compiles regular expressions and JSON paths
+func (t *TargetStatus) prepare() error {
+	t.regex = make([]*regexp.Regexp, 0, len(t.Regex))
+	for _, expression := range t.Regex {
+		re, err := regexp.Compile(expression)
+		if err != nil {
+			return fmt.Errorf("regex: invalid regular expression %q: %s", expression, err.Error())
+		}
+		t.regex = append(t.regex, re)
+	}
+
+	for _, assertion := range t.JSON {
+		err := assertion.prepare()
+		if err != nil {
+			return fmt.Errorf("json: %s", err.Error())
+		}
+	}
+
+	return nil
+}
+
+// checkBody checks the response body against all body assertions. It returns the worst status of all
+// failed assertions (or green) and the reasons for the failures.
+func (t *TargetStatus) checkBody(body []byte) (Status, []string) {
+	status := STATUS_GREEN
+	failures := make([]string, 0)
+
+	if len(t.Body) > 0 && !bytes.Equal(body, t.Body) {
+		status = STATUS_RED
+		failures = append(failures, "body does not equal targetStatus.body")
+	}
+
+	for _, text := range t.Contains {
+		if !bytes.Contains(body, []byte(text)) {
+			status = STATUS_RED
+			failures = append(failures, fmt.Sprintf("body does not contain %q", text))
+		}
+	}
+
+	for _, text := range t.NotContains {
+		if bytes.Contains(body, []byte(text)) {
+			status = STATUS_RED
+			failures = append(failures, fmt.Sprintf("body contains %q", text))
+		}
+	}
+
+	for _, re := range t.regex {
+		if !re.Match(body) {
+			status = STATUS_RED
+			failures = append(failures, fmt.Sprintf("body does not match %q", re.String()))
+		}
+	}
+
+	if len(t.JSON) > 0 {
+		jsonStatus, jsonFailures := checkJSONAssertions(t.JSON, body)
+		status = worseStatus(status, jsonStatus)
+		failures = append(failures, jsonFailures...)
+	}
+
+	return status, failures
+}
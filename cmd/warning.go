@@ -0,0 +1,45 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// WarningStatus describes when an otherwise successful endpoint is considered degraded (yellow)
+type WarningStatus struct {
+	ResponseTime float64 `yaml:"responseTime,omitempty" json:"responseTime,omitempty"`
+	Codes        []int   `yaml:"codes,omitempty" json:"codes,omitempty"`
+	Body         string  `yaml:"body,omitempty" json:"body,omitempty"`
+}
+
+// hasCode returns whether the code is configured as degraded
+func (w *WarningStatus) hasCode(code int) bool {
+	for _, c := range w.Codes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+// applyWarning sets green results to yellow if one of the warning conditions of the endpoint applies.
+// Degraded response codes are handled by the probers, as they would otherwise lead to red results.
+func applyWarning(endpoint *Endpoint, result *Result) {
+	if result.Status != STATUS_GREEN {
+		return
+	}
+
+	warning := endpoint.Warning
+	if warning.ResponseTime > 0 && result.RequestDuration > warning.ResponseTime {
+		result.Status = STATUS_YELLOW
+		result.FailedAssertions = append(result.FailedAssertions, fmt.Sprintf("response time %.3fs exceeds %gs", result.RequestDuration, warning.ResponseTime))
+	}
+	if warning.hasCode(result.Code) {
+		result.Status = STATUS_YELLOW
+		result.FailedAssertions = append(result.FailedAssertions, fmt.Sprintf("response code %d is degraded", result.Code))
+	}
+	if warning.Body != "" && bytes.Contains(result.Body, []byte(warning.Body)) {
+		result.Status = STATUS_YELLOW
+		result.FailedAssertions = append(result.FailedAssertions, fmt.Sprintf("body contains %q", warning.Body))
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"sync"
+)
+
+const (
+	workersDefault           = 16
+	workersDefaultMaxPerHost = 4
+)
+
+type workerTask struct {
+	host string
+	run  func()
+}
+
+// WorkerPool runs the checks with a limited number of concurrent workers and a limit of concurrent
+// checks per host. Tasks are started in the order they were submitted, unless their host is busy.
+type WorkerPool struct {
+	mutex      sync.Mutex
+	cond       *sync.Cond
+	pending    []*workerTask
+	running    int
+	hosts      map[string]int
+	workers    int
+	maxPerHost int
+	closed     bool
+}
+
+func NewWorkerPool(workers int, maxPerHost int) *WorkerPool {
+	p := &WorkerPool{
+		pending: make([]*workerTask, 0),
+		hosts:   make(map[string]int),
+	}
+	p.cond = sync.NewCond(&p.mutex)
+	p.SetLimits(workers, maxPerHost)
+	go p.dispatch()
+	return p
+}
+
+// SetLimits changes the number of workers and the number of concurrent tasks per host. Running
+// tasks are not affected.
+func (p *WorkerPool) SetLimits(workers int, maxPerHost int) {
+	p.mutex.Lock()
+	p.workers = workers
+	p.maxPerHost = maxPerHost
+	p.mutex.Unlock()
+	p.cond.Broadcast()
+}
+
+// Submit queues the task. Tasks for the empty host are only limited by the number of workers.
+func (p *WorkerPool) Submit(host string, run func()) {
+	p.mutex.Lock()
+	p.pending = append(p.pending, &workerTask{host: host, run: run})
+	p.mutex.Unlock()
+	p.cond.Signal()
+}
+
+// QueueDepth returns the number of tasks waiting for a worker
+func (p *WorkerPool) QueueDepth() int {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return len(p.pending)
+}
+
+// Close stops starting new tasks
+func (p *WorkerPool) Close() {
+	p.mutex.Lock()
+	p.closed = true
+	p.mutex.Unlock()
+	p.cond.Broadcast()
+}
+
+func (p *WorkerPool) dispatch() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	for !p.closed {
+		task := p.nextTask()
+		if task == nil {
+			p.cond.Wait()
+			continue
+		}
+
+		p.running++
+		p.hosts[task.host]++
+		go p.work(task)
+	}
+}
+
+// nextTask removes and returns the first pending task that may be started now or nil if there is
+// none. Must be called with the mutex locked.
+func (p *WorkerPool) nextTask() *workerTask {
+	if p.running >= p.workers {
+		return nil
+	}
+
+	for i, task := range p.pending {
+		if task.host == "" || p.hosts[task.host] < p.maxPerHost {
+			p.pending = append(p.pending[:i], p.pending[i+1:]...)
+			return task
+		}
+	}
+	return nil
+}
+
+func (p *WorkerPool) work(task *workerTask) {
+	task.run()
+
+	p.mutex.Lock()
+	p.running--
+	p.hosts[task.host]--
+	if p.hosts[task.host] == 0 {
+		delete(p.hosts, task.host)
+	}
+	p.mutex.Unlock()
+	p.cond.Signal()
+}
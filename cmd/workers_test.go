@@ -0,0 +1,83 @@
+package main
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWorkerPoolLimits(t *testing.T) {
+	tests := []struct {
+		workers    int
+		maxPerHost int
+		hosts      []string
+	}{
+		{3, 1, []string{"a", "a", "a", "b", "b", "c"}},
+		{2, 4, []string{"a", "a", "a", "a", "a"}},
+		{4, 2, []string{"a", "a", "a", "", "", "", "", "b"}},
+	}
+
+	for _, test := range tests {
+		pool := NewWorkerPool(test.workers, test.maxPerHost)
+
+		mutex := sync.Mutex{}
+		running, maxRunning := 0, 0
+		hosts := make(map[string]int)
+		maxHosts := make(map[string]int)
+		order := make(map[string][]int)
+
+		done := sync.WaitGroup{}
+		for i, host := range test.hosts {
+			i, host := i, host
+			done.Add(1)
+			pool.Submit(host, func() {
+				defer done.Done()
+				mutex.Lock()
+				running++
+				hosts[host]++
+				if running > maxRunning {
+					maxRunning = running
+				}
+				if hosts[host] > maxHosts[host] {
+					maxHosts[host] = hosts[host]
+				}
+				order[host] = append(order[host], i)
+				mutex.Unlock()
+
+				time.Sleep(20 * time.Millisecond)
+
+				mutex.Lock()
+				running--
+				hosts[host]--
+				mutex.Unlock()
+			})
+		}
+		done.Wait()
+		pool.Close()
+
+		if maxRunning > test.workers {
+			t.Errorf("%v: expected at most %d tasks at the same time, got %d", test.hosts, test.workers, maxRunning)
+		}
+		for host, count := range maxHosts {
+			if host != "" && count > test.maxPerHost {
+				t.Errorf("%v: expected at most %d tasks for host %s, got %d", test.hosts, test.maxPerHost, host, count)
+			}
+		}
+		// Tasks of the same host start in the order they were submitted
+		for host, indexes := range order {
+			if host == "" || test.maxPerHost > 1 {
+				// Started at the same time
+				continue
+			}
+			for j := 1; j < len(indexes); j++ {
+				if indexes[j] < indexes[j-1] {
+					t.Errorf("%v: expected the tasks for host %s in order, got %v", test.hosts, host, indexes)
+					break
+				}
+			}
+		}
+		if depth := pool.QueueDepth(); depth != 0 {
+			t.Errorf("%v: expected an empty queue, got %d", test.hosts, depth)
+		}
+	}
+}
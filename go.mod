@@ -1,5 +1,5 @@
 module github.com/sirion/miStatusBoard
 
-go 1.19
+go 1.20
 
 require gopkg.in/yaml.v2 v2.4.0